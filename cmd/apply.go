@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/lukeberry99/devtool/internal/config"
+	"github.com/lukeberry99/devtool/internal/executor"
+	"github.com/lukeberry99/devtool/internal/installer"
+	"github.com/lukeberry99/devtool/internal/state"
+	"github.com/lukeberry99/devtool/internal/ui"
+)
+
+var applyCmd = &cobra.Command{
+	Use:   "apply <plan.json>",
+	Short: "Execute a plan made with devtool plan",
+	Long: `Execute exactly the commands of a saved plan.
+
+Apply refuses to start if the installed tools or the configuration of the
+planned tools changed since the plan was made, and refuses any command
+that is not in the plan.`,
+	Args: cobra.ExactArgs(1),
+	Run:  runApply,
+}
+
+func runApply(cmd *cobra.Command, args []string) {
+	verbose := viper.GetBool("verbose")
+
+	// Initialize logger
+	logger := ui.NewLogger(verbose)
+
+	logger.Section("🚀 Applying Plan")
+
+	plan, err := installer.LoadPlan(args[0])
+	if err != nil {
+		logger.Error(err.Error())
+		return
+	}
+
+	// Initialize state manager
+	stateManager, err := state.NewLocalStateManager()
+	if err != nil {
+		logger.Errorf("Failed to initialize state manager: %v", err)
+		return
+	}
+
+	// Load configuration
+	configFile := viper.GetString("config")
+	cfg, err := config.Load(configFile)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to load configuration: %v", err))
+		return
+	}
+
+	ctx, stop := signalContext()
+	defer stop()
+
+	runner := installer.NewToolRunner(logger, stateManager, installer.Options{
+		Verbose:   verbose,
+		Force:     plan.Force,
+		Jobs:      1,
+		ConfigDir: cfg.Dir(),
+		Retry:     cfg.Retry,
+		Timeouts:  cfg.Timeouts,
+		Homebrew:  cfg.Homebrew,
+		Executor:  executor.NewVerifier(executor.NewSystem(), plan.Steps()),
+	})
+
+	if err := runner.Apply(ctx, plan, cfg.Tools); err != nil {
+		logger.Error(fmt.Sprintf("Apply failed: %v", err))
+		return
+	}
+
+	logger.Success("Plan applied successfully!")
+}
+
+func init() {
+	rootCmd.AddCommand(applyCmd)
+}
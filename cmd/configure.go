@@ -6,6 +6,7 @@ import (
 
 	"github.com/lukeberry99/devtool/internal/config"
 	"github.com/lukeberry99/devtool/internal/configurator"
+	"github.com/lukeberry99/devtool/internal/state"
 	"github.com/lukeberry99/devtool/internal/ui"
 )
 
@@ -22,6 +23,7 @@ This includes .config directories, .local scripts, and dotfiles.`,
 func runConfigure(cmd *cobra.Command, args []string) {
 	dryRun := viper.GetBool("dry-run")
 	verbose := viper.GetBool("verbose")
+	profileName, _ := cmd.Flags().GetString("profile")
 
 	// Initialize logger
 	logger := ui.NewLogger(verbose)
@@ -40,6 +42,25 @@ func runConfigure(cmd *cobra.Command, args []string) {
 		return
 	}
 
+	// Deploy the dotfile mappings of the selected profile
+	if profileName == "" {
+		stateManager, err := state.NewLocalStateManager()
+		if err != nil {
+			logger.Errorf("Failed to initialize state manager: %v", err)
+			return
+		}
+		profileName = stateManager.SelectedProfile()
+	}
+
+	if profileName != "" {
+		profile, err := cfg.ResolveProfile(profileName)
+		if err != nil {
+			logger.Errorf("Failed to resolve profile: %v", err)
+			return
+		}
+		cfg.Dotfiles.Mappings = profile.Dotfiles
+	}
+
 	// Initialize dotfiles manager
 	dotfilesManager, err := configurator.NewDotfilesManager(cfg, logger, dryRun)
 	if err != nil {
@@ -58,4 +79,5 @@ func runConfigure(cmd *cobra.Command, args []string) {
 
 func init() {
 	rootCmd.AddCommand(configureCmd)
+	configureCmd.Flags().String("profile", "", "Deploy dotfiles for specific profile (defaults to the active profile)")
 }
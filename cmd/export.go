@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/lukeberry99/devtool/internal/brewfile"
+	"github.com/lukeberry99/devtool/internal/config"
+	"github.com/lukeberry99/devtool/internal/ui"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Render the configuration in other formats",
+}
+
+var exportBrewfileCmd = &cobra.Command{
+	Use:   "brewfile",
+	Short: "Render the enabled homebrew tools as a Brewfile",
+	Long: `Render the enabled homebrew tools, including casks, taps and
+homebrew_args, as a Brewfile for brew bundle. The Brewfile is printed unless
+--output is given.`,
+	Args: cobra.NoArgs,
+	Run:  runExportBrewfile,
+}
+
+func runExportBrewfile(cmd *cobra.Command, args []string) {
+	output, _ := cmd.Flags().GetString("output")
+	logger := ui.NewLogger(viper.GetBool("verbose"))
+
+	// Load configuration
+	configFile := viper.GetString("config")
+	cfg, err := config.Load(configFile)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to load configuration: %v", err))
+		return
+	}
+
+	b := brewfile.FromTools(cfg)
+
+	if output == "" {
+		if err := b.Write(os.Stdout); err != nil {
+			logger.Error(fmt.Sprintf("Failed to write Brewfile: %v", err))
+		}
+		return
+	}
+
+	var buf bytes.Buffer
+	b.Write(&buf)
+	if err := os.WriteFile(output, buf.Bytes(), 0644); err != nil {
+		logger.Error(fmt.Sprintf("Failed to write Brewfile: %v", err))
+		return
+	}
+	logger.Success(fmt.Sprintf("Wrote %d entries to %s", len(b.Entries), output))
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	exportCmd.AddCommand(exportBrewfileCmd)
+	exportBrewfileCmd.Flags().StringP("output", "o", "", "File to write the Brewfile to")
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/lukeberry99/devtool/internal/brewfile"
+	"github.com/lukeberry99/devtool/internal/config"
+	"github.com/lukeberry99/devtool/internal/ui"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Add tools to the configuration from other formats",
+}
+
+var importBrewfileCmd = &cobra.Command{
+	Use:   "brewfile <path>",
+	Short: "Add the formulae and casks of a Brewfile as homebrew tools",
+	Long: `Convert the brew, cask and tap entries of a Homebrew Bundle Brewfile into
+homebrew tools and add them to the configuration file.
+
+Tools that are already configured are left alone. Formulae and casks from a
+tap are installed by their fully qualified name. Entries devtool cannot
+represent, such as mas or vscode lines and Ruby conditionals, are listed and
+skipped. With --dry-run the new entries are printed instead of written.`,
+	Args: cobra.ExactArgs(1),
+	Run:  runImportBrewfile,
+}
+
+func runImportBrewfile(cmd *cobra.Command, args []string) {
+	dryRun := viper.GetBool("dry-run")
+	logger := ui.NewLogger(viper.GetBool("verbose"))
+
+	logger.Section("📥 Importing Brewfile")
+
+	// Load configuration
+	configFile := viper.GetString("config")
+	cfg, err := config.Load(configFile)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to load configuration: %v", err))
+		return
+	}
+
+	file, err := os.Open(args[0])
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to open Brewfile: %v", err))
+		return
+	}
+	defer file.Close()
+
+	b, err := brewfile.Parse(file)
+	if err != nil {
+		logger.Error(err.Error())
+		return
+	}
+
+	result := brewfile.ToTools(b, cfg)
+	for _, note := range result.Notes {
+		logger.Warn(note)
+	}
+
+	// Taps are not tools; show what to add to the homebrew section
+	if len(result.Taps) > 0 {
+		logger.Info("Add these taps to homebrew.taps:")
+		for _, tap := range result.Taps {
+			if tap.URL != "" {
+				fmt.Printf("    - {name: %q, url: %q}\n", tap.Name, tap.URL)
+			} else {
+				fmt.Printf("    - %q\n", tap.Name)
+			}
+		}
+	}
+
+	if len(result.Names) == 0 {
+		logger.Success("Nothing to import")
+		return
+	}
+
+	if dryRun {
+		block, err := config.RenderTools(result.Names, result.Tools, 2)
+		if err != nil {
+			logger.Error(err.Error())
+			return
+		}
+		logger.Info(fmt.Sprintf("[DRY RUN] Would add %d tools to %s:", len(result.Names), cfg.Path()))
+		fmt.Print(block)
+		return
+	}
+
+	if err := config.AddTools(cfg.Path(), result.Names, result.Tools); err != nil {
+		logger.Error(fmt.Sprintf("Failed to update configuration: %v", err))
+		return
+	}
+
+	for _, name := range result.Names {
+		logger.Step(name)
+	}
+	logger.Success(fmt.Sprintf("Added %d tools to %s", len(result.Names), cfg.Path()))
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+	importCmd.AddCommand(importBrewfileCmd)
+}
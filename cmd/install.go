@@ -8,6 +8,7 @@ import (
 
 	"github.com/lukeberry99/devtool/internal/config"
 	"github.com/lukeberry99/devtool/internal/installer"
+	"github.com/lukeberry99/devtool/internal/lockfile"
 	"github.com/lukeberry99/devtool/internal/state"
 	"github.com/lukeberry99/devtool/internal/ui"
 )
@@ -18,7 +19,10 @@ var installCmd = &cobra.Command{
 	Long: `Install development tools via Homebrew and custom build processes.
 	
 Equivalent to running ./install from the bash version.
-Optionally filter which tools to install with a pattern.`,
+Optionally filter which tools to install with a pattern, an explicit
+--tools list or a --profile. Patterns containing *, ? or [ are globs;
+anything else is matched as a regular expression. Dependencies of the
+selected tools are installed as well.`,
 	Args: cobra.MaximumNArgs(1),
 	Run:  runInstall,
 }
@@ -27,6 +31,12 @@ func runInstall(cmd *cobra.Command, args []string) {
 	dryRun := viper.GetBool("dry-run")
 	verbose := viper.GetBool("verbose")
 	force, _ := cmd.Flags().GetBool("force")
+	rebuild, _ := cmd.Flags().GetBool("rebuild")
+	locked, _ := cmd.Flags().GetBool("locked")
+	atomic, _ := cmd.Flags().GetBool("atomic")
+	jobs, _ := cmd.Flags().GetInt("jobs")
+	toolNames, _ := cmd.Flags().GetStringSlice("tools")
+	profile, _ := cmd.Flags().GetString("profile")
 
 	// Initialize logger
 	logger := ui.NewLogger(verbose)
@@ -41,6 +51,10 @@ func runInstall(cmd *cobra.Command, args []string) {
 		logger.Step("FORCE MODE: Will reinstall tools even if they appear current")
 	}
 
+	if atomic {
+		logger.Step("ATOMIC MODE: All changes will be rolled back if any tool fails")
+	}
+
 	// Initialize state manager
 	stateManager, err := state.NewLocalStateManager()
 	if err != nil {
@@ -56,21 +70,85 @@ func runInstall(cmd *cobra.Command, args []string) {
 		return
 	}
 
+	// Narrow down to the requested tools, within the profile selected with
+	// profile use unless another one was asked for
+	if profile == "" {
+		profile = stateManager.SelectedProfile()
+	}
+	selection := installer.Selection{Tools: toolNames, Profile: profile}
+	if len(args) == 1 {
+		selection.Pattern = args[0]
+	}
+	tools, err := installer.SelectTools(cfg, selection)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to select tools: %v", err))
+		return
+	}
+
+	// Pin every tool to the lockfile when reproducing a locked install
+	lockPath := lockfile.Path(cfg.Dir())
+	var lock *lockfile.Lockfile
+	if locked {
+		if lock, err = lockfile.Load(lockPath); err != nil {
+			logger.Error(fmt.Sprintf("Cannot install --locked: %v", err))
+			return
+		}
+		if tools, err = installer.PinTools(tools, lock); err != nil {
+			logger.Error(err.Error())
+			return
+		}
+		logger.Step(fmt.Sprintf("LOCKED MODE: Installing the versions recorded in %s", lockPath))
+	}
+
+	// Stop cleanly on Ctrl-C
+	ctx, stop := signalContext()
+	defer stop()
+
 	// Initialize tool runner
-	runner := installer.NewToolRunner(logger, dryRun, verbose, force, stateManager)
+	runner := installer.NewToolRunner(logger, stateManager, installer.Options{
+		DryRun:    dryRun,
+		Verbose:   verbose,
+		Force:     force,
+		Rebuild:   rebuild,
+		Jobs:      jobs,
+		ConfigDir: cfg.Dir(),
+		Retry:     cfg.Retry,
+		Timeouts:  cfg.Timeouts,
+		Homebrew:  cfg.Homebrew,
+		Lock:      lock,
+		Atomic:    atomic,
+	})
 
 	// Install tools
-	if err := runner.InstallTools(cfg.Tools); err != nil {
+	if err := runner.InstallTools(ctx, tools); err != nil {
 		logger.Error(fmt.Sprintf("Installation failed: %v", err))
 		return
 	}
 
+	// Drop taps devtool added that nothing refers to anymore
+	if cfg.Homebrew.UntapUnused {
+		if err := runner.UntapUnused(ctx, cfg.Tools); err != nil {
+			logger.Warn(err.Error())
+		}
+	}
+
+	// Record what was installed so other machines can reproduce it
+	if !dryRun && !locked {
+		if err := runner.WriteLockfile(lockPath, tools, cfg.Tools); err != nil {
+			logger.Warn(fmt.Sprintf("Failed to update lockfile: %v", err))
+		}
+	}
+
 	logger.Success("Installation completed successfully!")
 }
 
 func init() {
 	rootCmd.AddCommand(installCmd)
 	installCmd.Flags().StringSlice("tools", []string{}, "Specific tools to install")
-	installCmd.Flags().String("profile", "", "Install tools for specific profile")
+	installCmd.Flags().String("profile", "", "Install tools for specific profile (defaults to the active profile)")
 	installCmd.Flags().Bool("force", false, "Force reinstall even if tools appear current")
+	installCmd.Flags().Bool("rebuild", false, "Rebuild source tools even if the commit and build steps are unchanged")
+	installCmd.Flags().Bool("locked", false, "Install exactly the versions recorded in devtool.lock, or fail")
+	installCmd.Flags().Bool("atomic", false, "Roll back every change made by this run if any tool fails")
+	installCmd.Flags().IntP("jobs", "j", 1, "Number of tools to install concurrently")
 }
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/lukeberry99/devtool/internal/config"
+	"github.com/lukeberry99/devtool/internal/installer"
+	"github.com/lukeberry99/devtool/internal/state"
+	"github.com/lukeberry99/devtool/internal/ui"
+)
+
+var outdatedCmd = &cobra.Command{
+	Use:   "outdated",
+	Short: "List installed tools with newer versions available",
+	Long: `List installed tools that have a newer version available.
+
+Homebrew tools are checked with brew outdated, source builds compare the
+checked-out commit with the configured upstream ref, and release tools
+tracking the latest release are compared with the newest release tag.`,
+	Args: cobra.NoArgs,
+	Run:  runOutdated,
+}
+
+func runOutdated(cmd *cobra.Command, args []string) {
+	verbose := viper.GetBool("verbose")
+
+	// Initialize logger
+	logger := ui.NewLogger(verbose)
+
+	// Initialize state manager
+	stateManager, err := state.NewLocalStateManager()
+	if err != nil {
+		logger.Errorf("Failed to initialize state manager: %v", err)
+		return
+	}
+
+	// Load configuration
+	configFile := viper.GetString("config")
+	cfg, err := config.Load(configFile)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to load configuration: %v", err))
+		return
+	}
+
+	// Stop cleanly on Ctrl-C
+	ctx, stop := signalContext()
+	defer stop()
+
+	runner := installer.NewToolRunner(logger, stateManager, installer.Options{
+		Verbose:   verbose,
+		ConfigDir: cfg.Dir(),
+		Retry:     cfg.Retry,
+		Timeouts:  cfg.Timeouts,
+	})
+
+	outdated, err := runner.OutdatedTools(ctx, cfg.Tools)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to check for outdated tools: %v", err))
+		return
+	}
+
+	if len(outdated) == 0 {
+		logger.Success("All tools are up to date")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TOOL\tSOURCE\tCURRENT\tLATEST")
+	for _, tool := range outdated {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", tool.Name, tool.Source, tool.Current, tool.Latest)
+	}
+	w.Flush()
+}
+
+func init() {
+	rootCmd.AddCommand(outdatedCmd)
+}
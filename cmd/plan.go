@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/lukeberry99/devtool/internal/config"
+	"github.com/lukeberry99/devtool/internal/installer"
+	"github.com/lukeberry99/devtool/internal/state"
+	"github.com/lukeberry99/devtool/internal/ui"
+)
+
+var planCmd = &cobra.Command{
+	Use:   "plan [pattern]",
+	Short: "Work out what install would change and save it for review",
+	Long: `Compute an install plan without changing anything. The plan lists every
+tool that would be installed, why, and the exact commands that would run.
+
+Review the saved plan, then run it with devtool apply. Tools are selected
+the same way as for install.`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runPlan,
+}
+
+func runPlan(cmd *cobra.Command, args []string) {
+	verbose := viper.GetBool("verbose")
+	output, _ := cmd.Flags().GetString("output")
+	force, _ := cmd.Flags().GetBool("force")
+	toolNames, _ := cmd.Flags().GetStringSlice("tools")
+	profile, _ := cmd.Flags().GetString("profile")
+
+	// Initialize logger
+	logger := ui.NewLogger(verbose)
+
+	logger.Section("📝 Planning Installation")
+
+	// Initialize state manager
+	stateManager, err := state.NewLocalStateManager()
+	if err != nil {
+		logger.Errorf("Failed to initialize state manager: %v", err)
+		return
+	}
+
+	// Load configuration
+	configFile := viper.GetString("config")
+	cfg, err := config.Load(configFile)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to load configuration: %v", err))
+		return
+	}
+
+	if profile == "" {
+		profile = stateManager.SelectedProfile()
+	}
+	selection := installer.Selection{Tools: toolNames, Profile: profile}
+	if len(args) == 1 {
+		selection.Pattern = args[0]
+	}
+	tools, err := installer.SelectTools(cfg, selection)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to select tools: %v", err))
+		return
+	}
+
+	ctx, stop := signalContext()
+	defer stop()
+
+	runner := installer.NewToolRunner(logger, stateManager, installer.Options{
+		DryRun:    true,
+		Verbose:   verbose,
+		Force:     force,
+		Jobs:      1,
+		ConfigDir: cfg.Dir(),
+		Retry:     cfg.Retry,
+		Timeouts:  cfg.Timeouts,
+		Homebrew:  cfg.Homebrew,
+	})
+
+	plan, err := runner.Plan(ctx, tools)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Planning failed: %v", err))
+		return
+	}
+
+	if err := plan.Save(output); err != nil {
+		logger.Error(err.Error())
+		return
+	}
+
+	logger.Section("Plan")
+	if len(plan.Actions) == 0 {
+		logger.Success("Everything is up to date; the plan has nothing to do")
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "TOOL\tSOURCE\tREASON\tCOMMANDS")
+		for _, action := range plan.Actions {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\n", action.Tool, action.Source, action.Reason, len(action.Commands))
+		}
+		w.Flush()
+	}
+
+	logger.Info(fmt.Sprintf("Saved %d commands to %s; run devtool apply %s to execute them", len(plan.Commands()), output, output))
+}
+
+func init() {
+	rootCmd.AddCommand(planCmd)
+	planCmd.Flags().StringP("output", "o", "plan.json", "File to write the plan to")
+	planCmd.Flags().StringSlice("tools", []string{}, "Specific tools to plan")
+	planCmd.Flags().String("profile", "", "Plan tools for specific profile (defaults to the active profile)")
+	planCmd.Flags().Bool("force", false, "Plan to reinstall tools even if they appear current")
+}
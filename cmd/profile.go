@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/lukeberry99/devtool/internal/config"
+	"github.com/lukeberry99/devtool/internal/state"
+	"github.com/lukeberry99/devtool/internal/ui"
+)
+
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Manage the active profile",
+	Long: `Profiles select which tools and dotfiles apply to this machine.
+
+Tools without a profile list belong to every profile. A profile can extend
+other profiles and include or exclude tools and dotfile mappings on top of
+what it inherits. The active profile is stored in the local state and is
+used by install and configure.`,
+}
+
+var profileListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List available profiles",
+	Args:  cobra.NoArgs,
+	Run:   runProfileList,
+}
+
+var profileShowCmd = &cobra.Command{
+	Use:   "show [profile]",
+	Short: "Show the tools and dotfiles a profile resolves to",
+	Args:  cobra.MaximumNArgs(1),
+	Run:   runProfileShow,
+}
+
+var profileUseCmd = &cobra.Command{
+	Use:   "use <profile>",
+	Short: "Make a profile the active profile on this machine",
+	Args:  cobra.ExactArgs(1),
+	Run:   runProfileUse,
+}
+
+// loadProfileContext loads the configuration and state every profile
+// subcommand needs.
+func loadProfileContext(logger *ui.Logger) (*config.Config, *state.LocalStateManager, bool) {
+	stateManager, err := state.NewLocalStateManager()
+	if err != nil {
+		logger.Errorf("Failed to initialize state manager: %v", err)
+		return nil, nil, false
+	}
+
+	cfg, err := config.Load(viper.GetString("config"))
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to load configuration: %v", err))
+		return nil, nil, false
+	}
+
+	return cfg, stateManager, true
+}
+
+func runProfileList(cmd *cobra.Command, args []string) {
+	logger := ui.NewLogger(viper.GetBool("verbose"))
+
+	cfg, stateManager, ok := loadProfileContext(logger)
+	if !ok {
+		return
+	}
+
+	active := stateManager.ActiveProfile()
+	if active == "" {
+		active = config.DefaultProfile
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, name := range cfg.ProfileNames() {
+		marker := " "
+		if name == active {
+			marker = "*"
+		}
+		fmt.Fprintf(w, "%s %s\t%s\n", marker, name, cfg.Profiles[name].Description)
+	}
+	w.Flush()
+}
+
+func runProfileShow(cmd *cobra.Command, args []string) {
+	logger := ui.NewLogger(viper.GetBool("verbose"))
+
+	cfg, stateManager, ok := loadProfileContext(logger)
+	if !ok {
+		return
+	}
+
+	name := stateManager.ActiveProfile()
+	if len(args) == 1 {
+		name = args[0]
+	}
+
+	profile, err := cfg.ResolveProfile(name)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to resolve profile: %v", err))
+		return
+	}
+
+	logger.Section(fmt.Sprintf("Profile %s", profile.Name))
+	if len(profile.Chain) > 1 {
+		logger.Info(fmt.Sprintf("Inherits: %s", strings.Join(profile.Chain[:len(profile.Chain)-1], ", ")))
+	}
+
+	tools := make([]string, 0, len(profile.Tools))
+	for tool, toolConfig := range profile.Tools {
+		if !toolConfig.Enabled {
+			tool += " (disabled)"
+		}
+		tools = append(tools, tool)
+	}
+	sort.Strings(tools)
+
+	logger.Info(fmt.Sprintf("Tools (%d):", len(tools)))
+	for _, tool := range tools {
+		logger.Step(tool)
+	}
+
+	sources := make([]string, 0, len(profile.Dotfiles))
+	for source := range profile.Dotfiles {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+
+	logger.Info(fmt.Sprintf("Dotfiles (%d):", len(sources)))
+	for _, source := range sources {
+		logger.Step(fmt.Sprintf("%s → %s", source, profile.Dotfiles[source]))
+	}
+}
+
+func runProfileUse(cmd *cobra.Command, args []string) {
+	logger := ui.NewLogger(viper.GetBool("verbose"))
+
+	cfg, stateManager, ok := loadProfileContext(logger)
+	if !ok {
+		return
+	}
+
+	profile, err := cfg.ResolveProfile(args[0])
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to resolve profile: %v", err))
+		return
+	}
+
+	stateManager.SetActiveProfile(profile.Name)
+	if err := stateManager.Save(); err != nil {
+		logger.Error(fmt.Sprintf("Failed to save state: %v", err))
+		return
+	}
+
+	logger.Success(fmt.Sprintf("Active profile set to %s (%d tools)", profile.Name, len(profile.Tools)))
+}
+
+func init() {
+	rootCmd.AddCommand(profileCmd)
+	profileCmd.AddCommand(profileListCmd)
+	profileCmd.AddCommand(profileShowCmd)
+	profileCmd.AddCommand(profileUseCmd)
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/lukeberry99/devtool/internal/config"
+	"github.com/lukeberry99/devtool/internal/installer"
+	"github.com/lukeberry99/devtool/internal/state"
+	"github.com/lukeberry99/devtool/internal/ui"
+)
+
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback <tool>",
+	Short: "Switch a source-built tool back to its previous build",
+	Long: `Switch a source-built tool back to the build installed before the active one.
+
+Source builds are installed into ~/.devtool/opt/<tool>/<version-or-sha> and
+the most recent builds are kept (see build_config.retain), so rolling back
+only repoints the tool's symlinks. install keeps the rolled-back build until
+devtool upgrade <tool> or install --rebuild moves it on.`,
+	Args: cobra.ExactArgs(1),
+	Run:  runRollback,
+}
+
+func runRollback(cmd *cobra.Command, args []string) {
+	dryRun := viper.GetBool("dry-run")
+	verbose := viper.GetBool("verbose")
+
+	// Initialize logger
+	logger := ui.NewLogger(verbose)
+
+	// Initialize state manager
+	stateManager, err := state.NewLocalStateManager()
+	if err != nil {
+		logger.Errorf("Failed to initialize state manager: %v", err)
+		return
+	}
+
+	// Load configuration; state alone is enough to roll back
+	var toolConfig config.ToolConfig
+	configFile := viper.GetString("config")
+	if cfg, err := config.Load(configFile); err != nil {
+		logger.Warn(fmt.Sprintf("Failed to load configuration, using state only: %v", err))
+	} else {
+		toolConfig = cfg.Tools[args[0]]
+	}
+
+	runner := installer.NewToolRunner(logger, stateManager, installer.Options{
+		DryRun:  dryRun,
+		Verbose: verbose,
+	})
+
+	if err := runner.RollbackTool(args[0], toolConfig); err != nil {
+		logger.Error(fmt.Sprintf("Rollback failed: %v", err))
+		return
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(rollbackCmd)
+}
@@ -1,8 +1,11 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -21,6 +24,14 @@ func Execute() {
 	}
 }
 
+// signalContext is cancelled on the first Ctrl-C or SIGTERM, letting running
+// commands stop and state be saved. A second Ctrl-C exits immediately.
+func signalContext() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	context.AfterFunc(ctx, stop)
+	return ctx, stop
+}
+
 func init() {
 	cobra.OnInitialize(initConfig)
 
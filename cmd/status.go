@@ -2,23 +2,103 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"text/tabwriter"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/lukeberry99/devtool/internal/config"
+	"github.com/lukeberry99/devtool/internal/installer"
+	"github.com/lukeberry99/devtool/internal/state"
+	"github.com/lukeberry99/devtool/internal/ui"
 )
 
 var statusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Show the status of installed tools and configuration",
 	Long: `Display information about which tools are installed,
-their versions, and the status of configuration files.`,
-	Run: runStatus,
+their versions, and the status of configuration files.
+
+Installed versions are checked against the configured version, which may be
+a channel (latest, stable, nightly), an exact or partial version, or a
+constraint such as ">=0.10", "~1.22" or "^2".`,
+	Args: cobra.NoArgs,
+	Run:  runStatus,
 }
 
 func runStatus(cmd *cobra.Command, args []string) {
-	fmt.Println("Status command called")
+	verbose := viper.GetBool("verbose")
+
+	// Initialize logger
+	logger := ui.NewLogger(verbose)
+
+	// Initialize state manager
+	stateManager, err := state.NewLocalStateManager()
+	if err != nil {
+		logger.Errorf("Failed to initialize state manager: %v", err)
+		return
+	}
+
+	// Load configuration
+	configFile := viper.GetString("config")
+	cfg, err := config.Load(configFile)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to load configuration: %v", err))
+		return
+	}
+
+	// Without a profile selected every tool is reported
+	tools := cfg.Tools
+	if name := stateManager.SelectedProfile(); name != "" {
+		profile, err := cfg.ResolveProfile(name)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Failed to resolve profile: %v", err))
+			return
+		}
+		logger.Info(fmt.Sprintf("Profile: %s", profile.Name))
+		tools = profile.Tools
+	}
+
+	runner := installer.NewToolRunner(logger, stateManager, installer.Options{
+		Verbose:   verbose,
+		ConfigDir: cfg.Dir(),
+	})
+
+	counts := map[string]int{}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TOOL\tSOURCE\tINSTALLED\tWANTED\tSTATUS")
+	for _, report := range runner.ToolReports(tools) {
+		installed := report.Installed
+		if installed == "" {
+			installed = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", report.Name, report.Source, installed, report.Wanted, report.Status)
+		counts[report.Status]++
+	}
+	w.Flush()
+
+	logger.Info(fmt.Sprintf("%d ok, %d missing, %d mismatched", counts["ok"], counts["missing"], counts["mismatch"]))
+
+	// Services need brew, so they are only listed when some are configured
+	ctx, stop := signalContext()
+	defer stop()
+
+	services, err := runner.ServiceReports(ctx, tools)
+	if err != nil {
+		logger.Warn(fmt.Sprintf("Failed to check services: %v", err))
+		return
+	}
+	if len(services) == 0 {
+		return
+	}
 
-	// TODO: Implement actual status checking logic
-	fmt.Println("Status checking logic not yet implemented")
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SERVICE\tWANTED\tACTUAL\tSTATUS")
+	for _, report := range services {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", report.Name, report.Wanted, report.Actual, report.Status)
+	}
+	w.Flush()
 }
 
 func init() {
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/lukeberry99/devtool/internal/config"
+	"github.com/lukeberry99/devtool/internal/installer"
+	"github.com/lukeberry99/devtool/internal/state"
+	"github.com/lukeberry99/devtool/internal/ui"
+)
+
+var uninstallCmd = &cobra.Command{
+	Use:   "uninstall <tool...>",
+	Short: "Remove tools installed by devtool",
+	Long: `Remove tools using the installation source recorded in state.
+
+Homebrew tools are removed with brew uninstall, source builds run their
+uninstall_steps (or delete the files the build recorded), scripts run their
+uninstall hook, and downloaded binaries are deleted. The state and devtool.lock
+entries are removed afterwards.`,
+	Args: cobra.MinimumNArgs(1),
+	Run:  runUninstall,
+}
+
+func runUninstall(cmd *cobra.Command, args []string) {
+	dryRun := viper.GetBool("dry-run")
+	verbose := viper.GetBool("verbose")
+
+	// Initialize logger
+	logger := ui.NewLogger(verbose)
+
+	logger.Section("🗑️  Starting Uninstall")
+
+	if dryRun {
+		logger.Step("DRY RUN MODE: Nothing will be removed")
+	}
+
+	// Initialize state manager
+	stateManager, err := state.NewLocalStateManager()
+	if err != nil {
+		logger.Errorf("Failed to initialize state manager: %v", err)
+		return
+	}
+
+	// Load configuration; state alone is enough for most sources
+	tools := map[string]config.ToolConfig{}
+	configFile := viper.GetString("config")
+	cfg, err := config.Load(configFile)
+	if err != nil {
+		logger.Warn(fmt.Sprintf("Failed to load configuration, using state only: %v", err))
+	} else {
+		tools = cfg.Tools
+	}
+
+	configDir := ""
+	var homebrew config.HomebrewConfig
+	if cfg != nil {
+		configDir = cfg.Dir()
+		homebrew = cfg.Homebrew
+	}
+
+	// Stop cleanly on Ctrl-C
+	ctx, stop := signalContext()
+	defer stop()
+
+	runner := installer.NewToolRunner(logger, stateManager, installer.Options{
+		DryRun:    dryRun,
+		Verbose:   verbose,
+		ConfigDir: configDir,
+		Homebrew:  homebrew,
+	})
+
+	if err := runner.UninstallTools(ctx, args, tools); err != nil {
+		logger.Error(fmt.Sprintf("Uninstall failed: %v", err))
+		return
+	}
+
+	// Drop taps only the removed tools needed
+	if homebrew.UntapUnused {
+		if err := runner.UntapUnused(ctx, tools); err != nil {
+			logger.Warn(err.Error())
+		}
+	}
+
+	logger.Success("Uninstall completed successfully!")
+}
+
+func init() {
+	rootCmd.AddCommand(uninstallCmd)
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/lukeberry99/devtool/internal/config"
+	"github.com/lukeberry99/devtool/internal/installer"
+	"github.com/lukeberry99/devtool/internal/state"
+	"github.com/lukeberry99/devtool/internal/ui"
+)
+
+var upgradeCmd = &cobra.Command{
+	Use:   "upgrade [pattern]",
+	Short: "Upgrade outdated tools",
+	Long: `Upgrade installed tools that have a newer version available.
+
+Only tools reported by 'devtool outdated' are touched. Optionally limit the
+upgrade to tools whose name matches a glob or regular expression.`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runUpgrade,
+}
+
+func runUpgrade(cmd *cobra.Command, args []string) {
+	dryRun := viper.GetBool("dry-run")
+	verbose := viper.GetBool("verbose")
+
+	// Initialize logger
+	logger := ui.NewLogger(verbose)
+
+	logger.Section("⬆️  Starting Upgrade")
+
+	if dryRun {
+		logger.Step("DRY RUN MODE: No actual upgrades will be performed")
+	}
+
+	// Initialize state manager
+	stateManager, err := state.NewLocalStateManager()
+	if err != nil {
+		logger.Errorf("Failed to initialize state manager: %v", err)
+		return
+	}
+
+	// Load configuration
+	configFile := viper.GetString("config")
+	cfg, err := config.Load(configFile)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to load configuration: %v", err))
+		return
+	}
+
+	tools := cfg.Tools
+	if len(args) == 1 {
+		match, err := installer.NameMatcher(args[0])
+		if err != nil {
+			logger.Error(err.Error())
+			return
+		}
+		tools = make(map[string]config.ToolConfig)
+		for name, toolConfig := range cfg.Tools {
+			if match(name) {
+				tools[name] = toolConfig
+			}
+		}
+		if len(tools) == 0 {
+			logger.Error(fmt.Sprintf("No tools match %q", args[0]))
+			return
+		}
+	}
+
+	// Stop cleanly on Ctrl-C
+	ctx, stop := signalContext()
+	defer stop()
+
+	runner := installer.NewToolRunner(logger, stateManager, installer.Options{
+		DryRun:    dryRun,
+		Verbose:   verbose,
+		ConfigDir: cfg.Dir(),
+		Retry:     cfg.Retry,
+		Timeouts:  cfg.Timeouts,
+		Homebrew:  cfg.Homebrew,
+	})
+
+	outdated, err := runner.OutdatedTools(ctx, tools)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to check for outdated tools: %v", err))
+		return
+	}
+
+	if len(outdated) == 0 {
+		logger.Success("All tools are up to date")
+		return
+	}
+
+	if err := runner.UpgradeTools(ctx, outdated, tools); err != nil {
+		logger.Error(fmt.Sprintf("Upgrade failed: %v", err))
+		return
+	}
+
+	logger.Success("Upgrade completed successfully!")
+}
+
+func init() {
+	rootCmd.AddCommand(upgradeCmd)
+}
@@ -0,0 +1,417 @@
+// Package brewfile reads and writes the Brewfile format of Homebrew Bundle.
+//
+// Only plain entries are understood: a type, a quoted name and options, as in
+//
+//	tap "homebrew/cask-fonts"
+//	brew "neovim", args: ["HEAD"]
+//	cask "firefox", args: { no_quarantine: true }
+//
+// Brewfiles are Ruby, so anything else (conditionals, loops, method calls) is
+// reported as skipped rather than evaluated.
+package brewfile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Entry is one line of a Brewfile.
+type Entry struct {
+	Type string // "tap", "brew", "cask", "mas", "vscode", ...
+	Name string
+	// URL is the clone URL of a tap, when it is not the default GitHub one.
+	URL string
+	// Args are install flags as given to brew, e.g. --HEAD or --no-quarantine.
+	Args []string
+	// Options holds every other option as written, e.g. "restart_service": "true".
+	Options map[string]string
+	Line    int
+}
+
+// Line is a line of a Brewfile that is not a plain entry.
+type Line struct {
+	Number int
+	Text   string
+}
+
+type Brewfile struct {
+	Entries []Entry
+	Skipped []Line
+}
+
+// Parse reads a Brewfile. Lines it cannot read are collected in Skipped; only
+// read errors are returned.
+func Parse(r io.Reader) (*Brewfile, error) {
+	b := &Brewfile{}
+	scanner := bufio.NewScanner(r)
+	number := 0
+	for scanner.Scan() {
+		number++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		entry, err := parseEntry(text)
+		if err != nil {
+			b.Skipped = append(b.Skipped, Line{Number: number, Text: text})
+			continue
+		}
+		entry.Line = number
+		b.Entries = append(b.Entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read Brewfile: %w", err)
+	}
+	return b, nil
+}
+
+// Write renders the entries, one per line.
+func (b *Brewfile) Write(w io.Writer) error {
+	for _, entry := range b.Entries {
+		if _, err := fmt.Fprintln(w, entry.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// String renders the entry as a Brewfile line.
+func (e Entry) String() string {
+	parts := []string{fmt.Sprintf("%s %s", e.Type, strconv.Quote(e.Name))}
+	if e.URL != "" {
+		parts = append(parts, strconv.Quote(e.URL))
+	}
+	if len(e.Args) > 0 {
+		parts = append(parts, "args: "+renderArgs(e.Type, e.Args))
+	}
+	keys := make([]string, 0, len(e.Options))
+	for key := range e.Options {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		parts = append(parts, fmt.Sprintf("%s: %s", key, e.Options[key]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// renderArgs writes flags the way Homebrew Bundle reads them back: a list of
+// flag names for formulae and a hash for casks.
+func renderArgs(entryType string, args []string) string {
+	var items []string
+	for _, arg := range args {
+		flag := strings.TrimPrefix(arg, "--")
+		if entryType != "cask" {
+			items = append(items, strconv.Quote(flag))
+			continue
+		}
+		key, value, hasValue := strings.Cut(flag, "=")
+		key = strings.ReplaceAll(key, "-", "_")
+		if hasValue {
+			items = append(items, fmt.Sprintf("%s: %s", key, strconv.Quote(value)))
+		} else {
+			items = append(items, key+": true")
+		}
+	}
+	if entryType != "cask" {
+		return "[" + strings.Join(items, ", ") + "]"
+	}
+	return "{ " + strings.Join(items, ", ") + " }"
+}
+
+func parseEntry(text string) (Entry, error) {
+	tokens, err := lex(text)
+	if err != nil {
+		return Entry{}, err
+	}
+	p := &parser{tokens: tokens, text: text}
+
+	typ := p.next()
+	name := p.next()
+	if typ.kind != tokenIdent || name.kind != tokenString {
+		return Entry{}, fmt.Errorf("expected a type and a quoted name")
+	}
+	entry := Entry{Type: typ.text, Name: name.text}
+
+	for !p.done() {
+		if p.next().kind != tokenComma {
+			return Entry{}, fmt.Errorf("expected a comma")
+		}
+		// A second positional string is the URL of a tap
+		if p.peek().kind == tokenString && p.peekAt(1).kind != tokenArrow {
+			entry.URL = p.next().text
+			continue
+		}
+
+		key, err := p.key()
+		if err != nil {
+			return Entry{}, err
+		}
+		start := p.pos
+		v, err := p.value()
+		if err != nil {
+			return Entry{}, err
+		}
+
+		if key == "args" {
+			args, err := v.flags()
+			if err != nil {
+				return Entry{}, err
+			}
+			entry.Args = append(entry.Args, args...)
+			continue
+		}
+		if entry.Options == nil {
+			entry.Options = make(map[string]string)
+		}
+		entry.Options[key] = p.source(start, p.pos)
+	}
+	return entry, nil
+}
+
+type value struct {
+	scalar string
+	list   []value
+	hash   [][2]value
+	kind   int // tokenString, tokenIdent, tokenLBracket or tokenLBrace
+}
+
+// flags converts the value of an args option into brew flags, as Homebrew
+// Bundle does: ["HEAD"] gives --HEAD and { appdir: "~/Apps" } --appdir=~/Apps.
+func (v value) flags() ([]string, error) {
+	var flags []string
+	switch v.kind {
+	case tokenLBracket:
+		for _, item := range v.list {
+			if item.list != nil || item.hash != nil {
+				return nil, fmt.Errorf("unsupported args value")
+			}
+			flags = append(flags, "--"+strings.TrimPrefix(item.scalar, "--"))
+		}
+	case tokenLBrace:
+		for _, pair := range v.hash {
+			flag := "--" + strings.ReplaceAll(pair[0].scalar, "_", "-")
+			switch {
+			case pair[1].kind == tokenIdent && pair[1].scalar == "true":
+				flags = append(flags, flag)
+			case pair[1].kind == tokenIdent && pair[1].scalar == "false":
+			case pair[1].list == nil && pair[1].hash == nil:
+				flags = append(flags, flag+"="+pair[1].scalar)
+			default:
+				return nil, fmt.Errorf("unsupported args value")
+			}
+		}
+	default:
+		return nil, fmt.Errorf("args must be a list or a hash")
+	}
+	return flags, nil
+}
+
+const (
+	tokenIdent = iota
+	tokenString
+	tokenSymbol // :name
+	tokenKey    // name:
+	tokenArrow  // =>
+	tokenComma
+	tokenLBracket
+	tokenRBracket
+	tokenLBrace
+	tokenRBrace
+	tokenEOF
+)
+
+type token struct {
+	kind       int
+	text       string
+	start, end int
+}
+
+func lex(text string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(text) {
+		c := text[i]
+		start := i
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+			continue
+		case c == '#':
+			i = len(text)
+			continue
+		case c == '"' || c == '\'':
+			s, n, err := lexString(text[i:])
+			if err != nil {
+				return nil, err
+			}
+			i += n
+			tokens = append(tokens, token{kind: tokenString, text: s, start: start, end: i})
+			continue
+		case c == ',':
+			tokens = append(tokens, token{kind: tokenComma, start: start, end: i + 1})
+		case c == '[':
+			tokens = append(tokens, token{kind: tokenLBracket, start: start, end: i + 1})
+		case c == ']':
+			tokens = append(tokens, token{kind: tokenRBracket, start: start, end: i + 1})
+		case c == '{':
+			tokens = append(tokens, token{kind: tokenLBrace, start: start, end: i + 1})
+		case c == '}':
+			tokens = append(tokens, token{kind: tokenRBrace, start: start, end: i + 1})
+		case c == '=' && strings.HasPrefix(text[i:], "=>"):
+			i += 2
+			tokens = append(tokens, token{kind: tokenArrow, start: start, end: i})
+			continue
+		case c == ':' && i+1 < len(text) && isIdent(text[i+1]):
+			i++
+			for i < len(text) && isIdent(text[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenSymbol, text: text[start+1 : i], start: start, end: i})
+			continue
+		case isIdent(c):
+			for i < len(text) && (isIdent(text[i]) || text[i] == '.' || text[i] == '?') {
+				i++
+			}
+			word := text[start:i]
+			if i < len(text) && text[i] == ':' && (i+1 == len(text) || text[i+1] != ':') {
+				i++
+				tokens = append(tokens, token{kind: tokenKey, text: word, start: start, end: i})
+				continue
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: word, start: start, end: i})
+			continue
+		default:
+			return nil, fmt.Errorf("unexpected %q", c)
+		}
+		i++
+	}
+	return append(tokens, token{kind: tokenEOF, start: len(text), end: len(text)}), nil
+}
+
+// lexString reads a quoted string at the start of s and returns its value and
+// length. Only the escapes a Brewfile realistically uses are understood.
+func lexString(s string) (string, int, error) {
+	quote := s[0]
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == quote:
+			return b.String(), i + 1, nil
+		case c == '\\' && i+1 < len(s):
+			i++
+			if quote == '\'' && s[i] != '\'' && s[i] != '\\' {
+				b.WriteByte('\\')
+			}
+			b.WriteByte(s[i])
+		case c == '#' && quote == '"' && i+1 < len(s) && s[i+1] == '{':
+			return "", 0, fmt.Errorf("string interpolation is not supported")
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string")
+}
+
+func isIdent(c byte) bool {
+	return c == '_' || c == '-' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+type parser struct {
+	tokens []token
+	text   string
+	pos    int
+}
+
+func (p *parser) peek() token { return p.peekAt(0) }
+
+func (p *parser) peekAt(n int) token {
+	if p.pos+n >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[p.pos+n]
+}
+
+func (p *parser) next() token {
+	t := p.peek()
+	if p.pos < len(p.tokens)-1 {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) done() bool {
+	return p.peek().kind == tokenEOF
+}
+
+// key reads an option name: name:, :name => or "name" =>.
+func (p *parser) key() (string, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenKey:
+		return t.text, nil
+	case tokenSymbol, tokenString:
+		if p.next().kind != tokenArrow {
+			return "", fmt.Errorf("expected =>")
+		}
+		return t.text, nil
+	}
+	return "", fmt.Errorf("expected an option")
+}
+
+func (p *parser) value() (value, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenString, tokenIdent:
+		return value{scalar: t.text, kind: t.kind}, nil
+	case tokenSymbol:
+		return value{scalar: t.text, kind: tokenString}, nil
+	case tokenLBracket:
+		v := value{kind: tokenLBracket, list: []value{}}
+		for p.peek().kind != tokenRBracket {
+			item, err := p.value()
+			if err != nil {
+				return value{}, err
+			}
+			v.list = append(v.list, item)
+			if p.peek().kind == tokenComma {
+				p.next()
+			} else if p.peek().kind != tokenRBracket {
+				return value{}, fmt.Errorf("expected ]")
+			}
+		}
+		p.next()
+		return v, nil
+	case tokenLBrace:
+		v := value{kind: tokenLBrace, hash: [][2]value{}}
+		for p.peek().kind != tokenRBrace {
+			key, err := p.key()
+			if err != nil {
+				return value{}, err
+			}
+			item, err := p.value()
+			if err != nil {
+				return value{}, err
+			}
+			v.hash = append(v.hash, [2]value{{scalar: key, kind: tokenString}, item})
+			if p.peek().kind == tokenComma {
+				p.next()
+			} else if p.peek().kind != tokenRBrace {
+				return value{}, fmt.Errorf("expected }")
+			}
+		}
+		p.next()
+		return v, nil
+	}
+	return value{}, fmt.Errorf("expected a value")
+}
+
+// source returns the text of tokens [from, to).
+func (p *parser) source(from, to int) string {
+	return strings.TrimSpace(p.text[p.tokens[from].start:p.tokens[to-1].end])
+}
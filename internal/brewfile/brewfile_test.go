@@ -0,0 +1,147 @@
+package brewfile
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lukeberry99/devtool/internal/config"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want Entry
+	}{
+		{"brew", `brew "jq"`, Entry{Type: "brew", Name: "jq"}},
+		{"single quotes", `brew 'jq'`, Entry{Type: "brew", Name: "jq"}},
+		{"trailing comment", `brew "jq" # json`, Entry{Type: "brew", Name: "jq"}},
+		{"tapped formula", `brew "acme/tools/widget"`, Entry{Type: "brew", Name: "acme/tools/widget"}},
+		{"args list", `brew "neovim", args: ["HEAD", "--with-lua"]`, Entry{Type: "brew", Name: "neovim", Args: []string{"--HEAD", "--with-lua"}}},
+		{"args hash", `cask "firefox", args: { appdir: "~/Apps", no_quarantine: true, require_sha: false }`, Entry{Type: "cask", Name: "firefox", Args: []string{"--appdir=~/Apps", "--no-quarantine"}}},
+		{"hash rocket args", `cask "firefox", :args => { :no_quarantine => true }`, Entry{Type: "cask", Name: "firefox", Args: []string{"--no-quarantine"}}},
+		{"options", `brew "syncthing", restart_service: :changed, link: true`, Entry{Type: "brew", Name: "syncthing", Options: map[string]string{"restart_service": ":changed", "link": "true"}}},
+		{"tap", `tap "homebrew/cask-fonts"`, Entry{Type: "tap", Name: "homebrew/cask-fonts"}},
+		{"tap with url", `tap "acme/tools", "https://git.acme.dev/homebrew-tools.git"`, Entry{Type: "tap", Name: "acme/tools", URL: "https://git.acme.dev/homebrew-tools.git"}},
+		{"mas", `mas "Xcode", id: 497799835`, Entry{Type: "mas", Name: "Xcode", Options: map[string]string{"id": "497799835"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := Parse(strings.NewReader(tt.line))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(b.Skipped) > 0 || len(b.Entries) != 1 {
+				t.Fatalf("Parse(%s) = %+v", tt.line, b)
+			}
+			tt.want.Line = 1
+			if got := b.Entries[0]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%s) = %+v, want %+v", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSkipsUnreadableLines(t *testing.T) {
+	input := `# Taps
+tap "homebrew/bundle"
+
+brew "jq"
+brew "git" if OS.mac?
+brew "ripgrep", args: ["HEAD"
+cask "firefox", args: { appdir: }
+brew "fzf" "extra"
+brew "#{name}"
+brew "unterminated
+brew jq
+cask_args appdir: "~/Applications"
+brew "fd", args: [["nested"]]
+`
+	b, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, entry := range b.Entries {
+		names = append(names, entry.Name)
+	}
+	if want := []string{"homebrew/bundle", "jq"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("entries = %q, want %q", names, want)
+	}
+
+	var skipped []int
+	for _, line := range b.Skipped {
+		skipped = append(skipped, line.Number)
+	}
+	if want := []int{5, 6, 7, 8, 9, 10, 11, 12, 13}; !reflect.DeepEqual(skipped, want) {
+		t.Errorf("skipped lines = %v, want %v", skipped, want)
+	}
+}
+
+func TestEntryStringRoundTrip(t *testing.T) {
+	lines := []string{
+		`tap "acme/tools", "https://git.acme.dev/homebrew-tools.git"`,
+		`brew "neovim", args: ["HEAD"]`,
+		`brew "syncthing", restart_service: :changed`,
+		`cask "firefox", args: { appdir: "~/Apps", no_quarantine: true }`,
+	}
+
+	for _, line := range lines {
+		b, err := Parse(strings.NewReader(line))
+		if err != nil || len(b.Entries) != 1 {
+			t.Fatalf("Parse(%s) = %+v, %v", line, b, err)
+		}
+		if got := b.Entries[0].String(); got != line {
+			t.Errorf("String() = %s, want %s", got, line)
+		}
+	}
+}
+
+func TestImportExportRoundTrip(t *testing.T) {
+	input := `tap "acme/tools", "https://git.acme.dev/homebrew-tools.git"
+tap "nikitabobko/tap"
+brew "acme/tools/widget"
+brew "jq"
+brew "neovim", args: ["HEAD"]
+brew "syncthing", restart_service: :changed
+brew "postgresql@16", start_service: true
+cask "firefox", args: { no_quarantine: true }
+cask "nikitabobko/tap/aerospace"
+`
+	b, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	imported := ToTools(b, &config.Config{})
+	if len(imported.Notes) > 0 {
+		t.Errorf("notes = %q", imported.Notes)
+	}
+	if want := []config.TapConfig{{Name: "acme/tools", URL: "https://git.acme.dev/homebrew-tools.git"}}; !reflect.DeepEqual(imported.Taps, want) {
+		t.Errorf("taps = %+v, want %+v", imported.Taps, want)
+	}
+
+	cfg := &config.Config{Tools: imported.Tools, Homebrew: config.HomebrewConfig{Taps: imported.Taps}}
+	var out bytes.Buffer
+	if err := FromTools(cfg).Write(&out); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `tap "acme/tools", "https://git.acme.dev/homebrew-tools.git"
+tap "nikitabobko/tap"
+brew "acme/tools/widget"
+brew "jq"
+brew "neovim", args: ["HEAD"]
+brew "postgresql@16", start_service: true
+brew "syncthing", restart_service: :changed
+cask "firefox", args: { no_quarantine: true }
+cask "nikitabobko/tap/aerospace"
+`
+	if out.String() != want {
+		t.Errorf("exported:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
@@ -0,0 +1,193 @@
+package brewfile
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/lukeberry99/devtool/internal/config"
+)
+
+// Import is the outcome of converting a Brewfile into tool entries.
+type Import struct {
+	// Names lists the new tools in Brewfile order.
+	Names []string
+	Tools map[string]config.ToolConfig
+	// Taps are tap lines that belong in homebrew.taps: taps with a custom URL
+	// and taps none of the new tools come from.
+	Taps []config.TapConfig
+	// Notes explain every entry that was skipped or only partly imported.
+	Notes []string
+}
+
+// ToTools converts the brew and cask entries of b into homebrew tools. Tools
+// already in cfg are left alone. Formulae and casks from a tap get it as their
+// tap.
+func ToTools(b *Brewfile, cfg *config.Config) *Import {
+	result := &Import{Tools: make(map[string]config.ToolConfig)}
+	type note struct {
+		line int
+		text string
+	}
+	var notes []note
+	var taps []Entry
+	used := make(map[string]bool)
+	notef := func(entry Entry, format string, args ...interface{}) {
+		notes = append(notes, note{entry.Line, fmt.Sprintf(format, args...)})
+	}
+
+	for _, entry := range b.Entries {
+		switch entry.Type {
+		case "brew", "cask":
+		case "tap":
+			taps = append(taps, entry)
+			continue
+		default:
+			notef(entry, "%s entries are not supported, skipped %s", entry.Type, entry.Name)
+			continue
+		}
+
+		name := entry.Name[strings.LastIndex(entry.Name, "/")+1:]
+		if _, ok := cfg.Tools[name]; ok {
+			notef(entry, "%s is already configured, skipped", name)
+			continue
+		}
+		if _, ok := result.Tools[name]; ok {
+			notef(entry, "%s is listed twice, kept the first entry", name)
+			continue
+		}
+
+		toolConfig := config.ToolConfig{
+			Source:  "homebrew",
+			Enabled: true,
+		}
+		if entry.Type == "cask" {
+			toolConfig.Cask = true
+		}
+		if name != entry.Name {
+			toolConfig.Tap = strings.ToLower(entry.Name[:strings.LastIndex(entry.Name, "/")])
+			used[toolConfig.Tap] = true
+		}
+		toolConfig.HomebrewArgs = entry.Args
+
+		for _, option := range sortedKeys(entry.Options) {
+			value := entry.Options[option]
+			switch {
+			case entry.Type == "brew" && option == "start_service" && value == "true":
+				toolConfig.Service = service(toolConfig.Service)
+			case entry.Type == "brew" && option == "restart_service" && (value == "true" || value == ":changed"):
+				toolConfig.Service = service(toolConfig.Service)
+				toolConfig.Service.RestartOnUpgrade = true
+				if value == "true" {
+					notef(entry, "restart_service of %s restarts the service on every brew bundle run; devtool restarts it only when %s is upgraded", entry.Name, name)
+				}
+			default:
+				notef(entry, "option %s of %s is not supported, ignored", option, entry.Name)
+			}
+		}
+
+		result.Names = append(result.Names, name)
+		result.Tools[name] = toolConfig
+	}
+
+	for _, entry := range taps {
+		name := strings.ToLower(entry.Name)
+		if cfg.Homebrew.TapURL(name) != "" || (used[name] && entry.URL == "") {
+			continue
+		}
+		configured := false
+		for _, tap := range cfg.Homebrew.Taps {
+			configured = configured || strings.EqualFold(tap.Name, name)
+		}
+		if !configured {
+			result.Taps = append(result.Taps, config.TapConfig{Name: name, URL: entry.URL})
+		}
+	}
+
+	for _, line := range b.Skipped {
+		notes = append(notes, note{line.Number, fmt.Sprintf("could not read %q, skipped", line.Text)})
+	}
+	sort.SliceStable(notes, func(i, j int) bool { return notes[i].line < notes[j].line })
+	for _, n := range notes {
+		result.Notes = append(result.Notes, fmt.Sprintf("line %d: %s", n.line, n.text))
+	}
+	return result
+}
+
+// service returns the service of a tool, running at login.
+func service(existing *config.ServiceConfig) *config.ServiceConfig {
+	if existing == nil {
+		existing = &config.ServiceConfig{}
+	}
+	existing.RunAtLogin = true
+	return existing
+}
+
+// FromTools renders the configured taps and the enabled homebrew tools of cfg
+// as a Brewfile: taps first, then formulae, then casks, each sorted by name.
+func FromTools(cfg *config.Config) *Brewfile {
+	taps := make(map[string]bool)
+	for _, tap := range cfg.Homebrew.Taps {
+		taps[strings.ToLower(tap.Name)] = true
+	}
+	var brews, casks []Entry
+
+	for name, toolConfig := range cfg.Tools {
+		if !toolConfig.Enabled || toolConfig.Source != "homebrew" {
+			continue
+		}
+
+		entry := Entry{Type: "brew", Name: name}
+		if toolConfig.Cask {
+			entry.Type = "cask"
+		}
+		if toolConfig.Tap != "" {
+			entry.Name = toolConfig.Tap + "/" + name
+			taps[strings.ToLower(toolConfig.Tap)] = true
+		}
+		for _, arg := range toolConfig.HomebrewArgs {
+			switch {
+			case arg == "--cask":
+				entry.Type = "cask"
+			case !strings.HasPrefix(arg, "-") && strings.Count(arg, "/") == 2:
+				entry.Name = arg
+				taps[arg[:strings.LastIndex(arg, "/")]] = true
+			default:
+				entry.Args = append(entry.Args, arg)
+			}
+		}
+
+		if service := toolConfig.Service; service != nil && service.RunAtLogin && entry.Type == "brew" {
+			if service.RestartOnUpgrade {
+				entry.Options = map[string]string{"restart_service": ":changed"}
+			} else {
+				entry.Options = map[string]string{"start_service": "true"}
+			}
+		}
+
+		if entry.Type == "cask" {
+			casks = append(casks, entry)
+		} else {
+			brews = append(brews, entry)
+		}
+	}
+
+	b := &Brewfile{}
+	for _, tap := range sortedKeys(taps) {
+		b.Entries = append(b.Entries, Entry{Type: "tap", Name: tap, URL: cfg.Homebrew.TapURL(tap)})
+	}
+	for _, entries := range [][]Entry{brews, casks} {
+		sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+		b.Entries = append(b.Entries, entries...)
+	}
+	return b
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
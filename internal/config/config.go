@@ -4,39 +4,140 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
+	path string
+
 	Version  string                `yaml:"version"`
 	Tools    map[string]ToolConfig `yaml:"tools"`
 	Profiles map[string]Profile    `yaml:"profiles"`
 	Dotfiles DotfilesConfig        `yaml:"dotfiles"`
 	Homebrew HomebrewConfig        `yaml:"homebrew"`
+	Retry    RetryConfig           `yaml:"retry"`
+	Timeouts TimeoutConfig         `yaml:"timeouts"`
 	Logging  LoggingConfig         `yaml:"logging"`
 	Sync     SyncConfig            `yaml:"sync"`
 }
 
 type ToolConfig struct {
-	Version         string       `yaml:"version"`
-	VersionCommand  string       `yaml:"version_command,omitempty"`
-	InstalledBinary string       `yaml:"installed_binary,omitempty"`
-	Cask            bool         `yaml:"cask,omitempty"`
-	AppName         string       `yaml:"app_name,omitempty"`
-	Source          string       `yaml:"source"` // "homebrew", "build", "script"
-	Dependencies    []string     `yaml:"dependencies"`
-	BuildConfig     *BuildConfig `yaml:"build_config,omitempty"`
-	HomebrewArgs    []string     `yaml:"homebrew_args,omitempty"`
-	Profile         []string     `yaml:"profile"`
-	Enabled         bool         `yaml:"enabled"`
+	Version         string            `yaml:"version"`
+	VersionCommand  string            `yaml:"version_command,omitempty"`
+	InstalledBinary string            `yaml:"installed_binary,omitempty"`
+	Cask            bool              `yaml:"cask,omitempty"`
+	AppName         string            `yaml:"app_name,omitempty"`
+	Source          string            `yaml:"source"` // "homebrew", "system", "build", "script", "download", "release", "go", "cargo", "pipx", "npm"
+	Dependencies    []string          `yaml:"dependencies"`
+	BuildConfig     *BuildConfig      `yaml:"build_config,omitempty"`
+	ScriptConfig    *ScriptConfig     `yaml:"script_config,omitempty"`
+	DownloadConfig  *DownloadConfig   `yaml:"download_config,omitempty"`
+	ReleaseConfig   *ReleaseConfig    `yaml:"release_config,omitempty"`
+	HomebrewArgs    []string          `yaml:"homebrew_args,omitempty"`
+	Tap             string            `yaml:"tap,omitempty"`      // homebrew tap the formula or cask comes from, e.g. "nikitabobko/tap"
+	Service         *ServiceConfig    `yaml:"service,omitempty"`  // background service of a homebrew formula
+	Package         string            `yaml:"package,omitempty"`  // module, crate or package name for go, cargo, pipx and npm sources
+	Packages        map[string]string `yaml:"packages,omitempty"` // system package names keyed by manager ("apt", "dnf", "pacman", "homebrew") or distro ID
+	Profile         []string          `yaml:"profile"`
+	Enabled         bool              `yaml:"enabled"`
+	Retry           *RetryConfig      `yaml:"retry,omitempty"` // overrides the global retry policy
+	// Timeout bounds the whole install of the tool and StepTimeout each
+	// command it runs; both override the global timeouts
+	Timeout     time.Duration `yaml:"timeout,omitempty"`
+	StepTimeout time.Duration `yaml:"step_timeout,omitempty"`
 }
 
 type BuildConfig struct {
-	Repository   string   `yaml:"repository"`
-	BuildSteps   []string `yaml:"build_steps"`
-	InstallSteps []string `yaml:"install_steps"`
-	Dependencies []string `yaml:"dependencies"`
+	Repository     string   `yaml:"repository"`
+	BuildSteps     []string `yaml:"build_steps"`
+	InstallSteps   []string `yaml:"install_steps"`
+	UninstallSteps []string `yaml:"uninstall_steps,omitempty"`
+	Dependencies   []string `yaml:"dependencies"`
+	// Builds are installed into their own prefix, exported to the steps as
+	// $PREFIX. Retain is how many prefixes are kept for rollback (default 3)
+	// and BinDir where the prefix's bin/ entries are linked (~/.local/bin).
+	Retain int    `yaml:"retain,omitempty"`
+	BinDir string `yaml:"bin_dir,omitempty"`
+}
+
+// ServiceConfig is the desired state of a formula's background service,
+// managed through brew services. A service that does not run at login is
+// kept stopped.
+type ServiceConfig struct {
+	RunAtLogin       bool `yaml:"run_at_login"`
+	RestartOnUpgrade bool `yaml:"restart_on_upgrade,omitempty"`
+}
+
+// ScriptConfig describes a tool installed by running a script. Exactly one of
+// Run (an inline script) or File (a path relative to the config file) is set.
+type ScriptConfig struct {
+	Run         string            `yaml:"run,omitempty"`
+	File        string            `yaml:"file,omitempty"`
+	Interpreter string            `yaml:"interpreter,omitempty"` // defaults to "sh"
+	Args        []string          `yaml:"args,omitempty"`
+	Env         map[string]string `yaml:"env,omitempty"`
+	WorkDir     string            `yaml:"workdir,omitempty"`
+	Check       string            `yaml:"check,omitempty"`     // exit 0 means already installed
+	Uninstall   string            `yaml:"uninstall,omitempty"` // inline script run by devtool uninstall
+}
+
+// DownloadConfig describes a tool fetched directly from a URL. The URL is a
+// text/template with .Version, .OS and .Arch (after os_map/arch_map) and the
+// raw .GOOS and .GOARCH values.
+type DownloadConfig struct {
+	URL           string            `yaml:"url"`
+	SHA256        string            `yaml:"sha256,omitempty"`
+	Checksums     map[string]string `yaml:"checksums,omitempty"` // keyed by "<goos>_<goarch>"
+	ArchiveConfig `yaml:",inline"`
+}
+
+// ReleaseConfig describes a tool installed from a release published through a
+// GitHub-compatible releases API. Asset and ChecksumAsset are glob patterns
+// rendered with the same data as download URLs plus .Tag.
+type ReleaseConfig struct {
+	Repository      string `yaml:"repository"`                  // "owner/name"
+	APIURL          string `yaml:"api_url,omitempty"`           // defaults to https://api.github.com
+	Asset           string `yaml:"asset,omitempty"`             // chosen by OS/arch when empty
+	ChecksumAsset   string `yaml:"checksum_asset,omitempty"`    // detected when empty
+	SendGitHubToken bool   `yaml:"send_github_token,omitempty"` // also send GITHUB_TOKEN to api_url, e.g. GitHub Enterprise
+	ArchiveConfig   `yaml:",inline"`
+}
+
+// ArchiveConfig controls how a downloaded artifact is verified and unpacked
+// and which binaries from it are installed.
+type ArchiveConfig struct {
+	// InsecureSkipChecksum installs the artifact even though no sha256 is
+	// known for it; without it a missing checksum is an error
+	InsecureSkipChecksum bool              `yaml:"insecure_skip_checksum,omitempty"`
+	Format               string            `yaml:"format,omitempty"` // "tar.gz", "tar.xz", "tar.bz2", "zip", "binary"; inferred when empty
+	StripComponents      int               `yaml:"strip_components,omitempty"`
+	Binaries             []string          `yaml:"binaries,omitempty"` // "path/in/archive" or "path/in/archive:name"
+	BinDir               string            `yaml:"bin_dir,omitempty"`  // defaults to ~/.local/bin
+	OSMap                map[string]string `yaml:"os_map,omitempty"`
+	ArchMap              map[string]string `yaml:"arch_map,omitempty"`
+}
+
+// RetryConfig controls how network-bound operations (git clone/fetch, brew
+// install/update, downloads) are retried. Zero values fall back to the global
+// policy and then to the defaults: 3 attempts, 2s backoff doubling up to 30s,
+// 20% jitter. Patterns and exit codes add to the built-in classification.
+type RetryConfig struct {
+	Attempts   int           `yaml:"attempts,omitempty"`
+	Backoff    time.Duration `yaml:"backoff,omitempty"`
+	MaxBackoff time.Duration `yaml:"max_backoff,omitempty"`
+	Jitter     float64       `yaml:"jitter,omitempty"`     // fraction of each delay that is randomized
+	Patterns   []string      `yaml:"patterns,omitempty"`   // case-insensitive stderr substrings that mark a failure retryable
+	ExitCodes  []int         `yaml:"exit_codes,omitempty"` // exit codes that are always retryable
+}
+
+// TimeoutConfig holds the default time limits for installing a tool and for a
+// single command of an install. Zero means no limit.
+type TimeoutConfig struct {
+	Tool time.Duration `yaml:"tool,omitempty"`
+	Step time.Duration `yaml:"step,omitempty"`
 }
 
 type DotfilesConfig struct {
@@ -47,8 +148,40 @@ type DotfilesConfig struct {
 }
 
 type HomebrewConfig struct {
-	AutoUpdate   bool `yaml:"auto_update"`
-	CleanupAfter bool `yaml:"cleanup_after"`
+	AutoUpdate   bool        `yaml:"auto_update"`
+	CleanupAfter bool        `yaml:"cleanup_after"`
+	Taps         []TapConfig `yaml:"taps,omitempty"`
+	// UntapUnused removes taps devtool added once no tool or entry in Taps
+	// refers to them
+	UntapUnused bool `yaml:"untap_unused,omitempty"`
+}
+
+// TapConfig is a Homebrew tap. It is written either as its name or, for taps
+// that are not on GitHub under the usual homebrew-<name> repository, as a
+// mapping with the URL to clone.
+type TapConfig struct {
+	Name string `yaml:"name"`
+	URL  string `yaml:"url,omitempty"`
+}
+
+func (t *TapConfig) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		t.Name = node.Value
+		return nil
+	}
+
+	type plain TapConfig
+	return node.Decode((*plain)(t))
+}
+
+// TapURL returns the URL configured for a tap, or "" for the default.
+func (h HomebrewConfig) TapURL(name string) string {
+	for _, tap := range h.Taps {
+		if strings.EqualFold(tap.Name, name) {
+			return tap.URL
+		}
+	}
+	return ""
 }
 
 type LoggingConfig struct {
@@ -59,8 +192,13 @@ type LoggingConfig struct {
 type Profile struct {
 	Name        string   `yaml:"name"`
 	Description string   `yaml:"description"`
+	Extends     []string `yaml:"extends"` // parent profiles, resolved first
 	Include     []string `yaml:"include"`
 	Exclude     []string `yaml:"exclude"`
+	// Dotfiles adds or overrides dotfile mappings; ExcludeDotfiles drops
+	// inherited mappings by source path
+	Dotfiles        map[string]string `yaml:"dotfiles"`
+	ExcludeDotfiles []string          `yaml:"exclude_dotfiles"`
 }
 
 type SyncConfig struct {
@@ -97,9 +235,28 @@ func Load(configPath string) (*Config, error) {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
 
+	if absPath, err := filepath.Abs(configPath); err == nil {
+		configPath = absPath
+	}
+	config.path = configPath
+
 	return &config, nil
 }
 
+// Path returns the file the configuration was loaded from.
+func (c *Config) Path() string {
+	return c.path
+}
+
+// Dir returns the directory containing the configuration file. Relative paths
+// in the configuration are resolved against it.
+func (c *Config) Dir() string {
+	if c.path == "" {
+		return ""
+	}
+	return filepath.Dir(c.path)
+}
+
 func getDefaultConfigPath() string {
 	if repoRoot := findRepoRoot(); repoRoot != "" {
 		return filepath.Join(repoRoot, "devtool", "configs", "devtool.yml")
@@ -0,0 +1,172 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RenderTools renders tool entries as they would appear under tools:, in the
+// order of names. Empty fields are left out.
+func RenderTools(names []string, tools map[string]ToolConfig, indent int) (string, error) {
+	var out strings.Builder
+	for _, name := range names {
+		var value yaml.Node
+		if err := value.Encode(tools[name]); err != nil {
+			return "", fmt.Errorf("failed to encode %s: %w", name, err)
+		}
+		compact(&value)
+
+		entry := &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{
+			{Kind: yaml.ScalarNode, Value: name},
+			&value,
+		}}
+		var buf bytes.Buffer
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(entry); err != nil {
+			return "", fmt.Errorf("failed to encode %s: %w", name, err)
+		}
+
+		for _, line := range strings.Split(strings.TrimRight(buf.String(), "\n"), "\n") {
+			out.WriteString(strings.Repeat(" ", indent) + line + "\n")
+		}
+	}
+	return out.String(), nil
+}
+
+// compact drops empty fields from an encoded tool and styles the rest like
+// the shipped configuration: quoted strings and inline lists.
+func compact(node *yaml.Node) {
+	var content []*yaml.Node
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		switch {
+		case value.Kind == yaml.ScalarNode && (value.Value == "" || value.Tag == "!!null"):
+			continue
+		case value.Kind != yaml.ScalarNode && len(value.Content) == 0:
+			continue
+		}
+		styleScalars(value)
+		content = append(content, key, value)
+	}
+	node.Content = content
+}
+
+func styleScalars(node *yaml.Node) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if node.Tag == "!!str" {
+			node.Style = yaml.DoubleQuotedStyle
+		}
+	case yaml.MappingNode:
+		// Keys stay plain, like the rest of the file
+		for i := 1; i < len(node.Content); i += 2 {
+			styleScalars(node.Content[i])
+		}
+	case yaml.SequenceNode:
+		node.Style = yaml.FlowStyle
+		for _, child := range node.Content {
+			styleScalars(child)
+		}
+	}
+}
+
+// AddTools appends tool entries to the tools: section of the configuration
+// file at path. The file is edited as text so comments and layout survive.
+func AddTools(path string, names []string, tools map[string]ToolConfig) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("failed to parse config file: %w", err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return fmt.Errorf("%s is not a YAML mapping", path)
+	}
+	root := doc.Content[0]
+
+	text := strings.TrimRight(string(data), "\n")
+	lines := strings.Split(text, "\n")
+	if text == "" {
+		lines = nil
+	}
+
+	// Find where the new entries go and how far they are indented
+	at, indent := len(lines), 2
+	section := -1
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "tools" {
+			section = i
+		}
+	}
+	switch {
+	case section < 0:
+		lines = append(lines, "", "tools:")
+		at = len(lines)
+	default:
+		key, value := root.Content[section], root.Content[section+1]
+		switch {
+		case value.Kind == yaml.ScalarNode && value.Tag == "!!null":
+			at = key.Line
+		case value.Kind == yaml.MappingNode && value.Style&yaml.FlowStyle != 0 && len(value.Content) == 0 && value.Line == key.Line:
+			// Turn tools: {} into a block mapping, keeping any trailing comment
+			line := lines[value.Line-1]
+			end := strings.Index(line[value.Column-1:], "}")
+			if end < 0 {
+				return fmt.Errorf("tools in %s is not a block mapping; add the tools by hand", path)
+			}
+			rest := strings.TrimSpace(line[value.Column+end:])
+			lines[value.Line-1] = strings.TrimRight(line[:value.Column-1], " ")
+			if rest != "" {
+				lines[value.Line-1] += " " + rest
+			}
+			at = key.Line
+		case value.Kind == yaml.MappingNode && value.Style&yaml.FlowStyle == 0 && len(value.Content) > 0:
+			indent = value.Content[0].Column - 1
+			if section+2 < len(root.Content) {
+				at = root.Content[section+2].Line - 1
+				// Keep the blank lines and comments that lead into the next section
+				for at > 0 && (strings.TrimSpace(lines[at-1]) == "" || strings.HasPrefix(lines[at-1], "#")) {
+					at--
+				}
+			}
+		default:
+			return fmt.Errorf("tools in %s is not a block mapping; add the tools by hand", path)
+		}
+	}
+
+	block, err := RenderTools(names, tools, indent)
+	if err != nil {
+		return err
+	}
+
+	edited := append(append(append([]string{}, lines[:at]...), strings.Split(strings.TrimRight(block, "\n"), "\n")...), lines[at:]...)
+	output := []byte(strings.Join(edited, "\n") + "\n")
+
+	// Never write a file that no longer loads
+	var check Config
+	if err := yaml.Unmarshal(output, &check); err != nil {
+		return fmt.Errorf("failed to add tools to %s: %w", path, err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, output, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
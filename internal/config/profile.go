@@ -0,0 +1,144 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefaultProfile is the profile used when none has been selected.
+const DefaultProfile = "default"
+
+// ResolvedProfile is the effective tool set and dotfile mappings of a profile
+// once inheritance, membership, includes and excludes are applied.
+type ResolvedProfile struct {
+	Name     string
+	Chain    []string // the profile and its ancestors, parents first
+	Tools    map[string]ToolConfig
+	Dotfiles map[string]string
+}
+
+// ResolveProfile computes the effective configuration for a profile.
+//
+// Tools without a profile list belong to every profile. Tools listing a
+// profile belong to it and to every profile extending it. Each profile then
+// adds its Include list and removes its Exclude list, after its parents, so a
+// child can re-include what a parent excluded. Dotfile mappings start from the
+// top-level mappings and are adjusted the same way.
+func (c *Config) ResolveProfile(name string) (*ResolvedProfile, error) {
+	if name == "" {
+		name = DefaultProfile
+	}
+
+	if _, defined := c.Profiles[name]; !defined && name != DefaultProfile && !c.profileReferenced(name) {
+		return nil, fmt.Errorf("profile %q is not defined", name)
+	}
+
+	resolved := &ResolvedProfile{
+		Name:     name,
+		Tools:    make(map[string]ToolConfig),
+		Dotfiles: make(map[string]string),
+	}
+
+	for tool, toolConfig := range c.Tools {
+		if len(toolConfig.Profile) == 0 {
+			resolved.Tools[tool] = toolConfig
+		}
+	}
+	for source, target := range c.Dotfiles.Mappings {
+		resolved.Dotfiles[source] = target
+	}
+
+	if err := c.applyProfile(resolved, name, nil, make(map[string]bool)); err != nil {
+		return nil, err
+	}
+
+	return resolved, nil
+}
+
+func (c *Config) applyProfile(resolved *ResolvedProfile, name string, path []string, applied map[string]bool) error {
+	for _, p := range path {
+		if p == name {
+			return fmt.Errorf("profile inheritance cycle: %s", strings.Join(append(path, name), " -> "))
+		}
+	}
+	if applied[name] {
+		return nil
+	}
+
+	profile, defined := c.Profiles[name]
+	if !defined && len(path) > 0 && !c.profileReferenced(name) {
+		return fmt.Errorf("profile %q extends unknown profile %q", path[len(path)-1], name)
+	}
+
+	for _, parent := range profile.Extends {
+		if err := c.applyProfile(resolved, parent, append(path, name), applied); err != nil {
+			return err
+		}
+	}
+	applied[name] = true
+	resolved.Chain = append(resolved.Chain, name)
+
+	for tool, toolConfig := range c.Tools {
+		for _, p := range toolConfig.Profile {
+			if p == name {
+				resolved.Tools[tool] = toolConfig
+				break
+			}
+		}
+	}
+
+	for _, tool := range profile.Include {
+		toolConfig, ok := c.Tools[tool]
+		if !ok {
+			return fmt.Errorf("profile %q includes unknown tool %q", name, tool)
+		}
+		resolved.Tools[tool] = toolConfig
+	}
+	for _, tool := range profile.Exclude {
+		delete(resolved.Tools, tool)
+	}
+
+	for source, target := range profile.Dotfiles {
+		resolved.Dotfiles[source] = target
+	}
+	for _, source := range profile.ExcludeDotfiles {
+		delete(resolved.Dotfiles, source)
+	}
+
+	return nil
+}
+
+// profileReferenced reports whether any tool lists the profile, which makes it
+// usable without a profiles entry.
+func (c *Config) profileReferenced(name string) bool {
+	for _, toolConfig := range c.Tools {
+		for _, p := range toolConfig.Profile {
+			if p == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ProfileNames returns every known profile: those defined under profiles,
+// those referenced by tools, and the default profile.
+func (c *Config) ProfileNames() []string {
+	seen := map[string]bool{DefaultProfile: true}
+	for name := range c.Profiles {
+		seen[name] = true
+	}
+	for _, toolConfig := range c.Tools {
+		for _, p := range toolConfig.Profile {
+			seen[p] = true
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,118 @@
+// Package executor runs the external commands the installer depends on.
+// Installers describe a command as a Command value and hand it to an
+// Executor, so the same code can run for real, be recorded by a dry run or be
+// answered by a scripted fake.
+package executor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Command describes one process to run.
+type Command struct {
+	Name string
+	Args []string
+	// Dir is the working directory; empty means the current one.
+	Dir string
+	// Env holds KEY=value pairs added to devtool's own environment.
+	Env    []string
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+	// Interactive commands may prompt on the terminal (sudo, vendor
+	// installers). They read stdin from the terminal when Stdin is unset and
+	// receive Ctrl-C directly.
+	Interactive bool
+	// ReadOnly commands only inspect the machine, so dry runs and plan
+	// verification let them through. Only set it for queries devtool knows
+	// to be harmless, never for shell from the configuration.
+	ReadOnly bool
+}
+
+// New returns a command for name and args.
+func New(name string, args ...string) Command {
+	return Command{Name: name, Args: args}
+}
+
+// String renders the command line as it could be typed into a shell,
+// including the directory it runs in and the environment it adds.
+func (c Command) String() string {
+	words := make([]string, 0, len(c.Env)+len(c.Args)+1)
+	for _, variable := range c.Env {
+		key, value, _ := strings.Cut(variable, "=")
+		words = append(words, key+"="+quote(value))
+	}
+	for _, word := range append([]string{c.Name}, c.Args...) {
+		words = append(words, quote(word))
+	}
+
+	line := strings.Join(words, " ")
+	if c.Dir != "" {
+		line = "cd " + quote(c.Dir) + " && " + line
+	}
+	return line
+}
+
+func quote(word string) string {
+	if word == "" {
+		return "''"
+	}
+	if strings.IndexFunc(word, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./=:,+@%^{}", r))
+	}) < 0 {
+		return word
+	}
+	return "'" + strings.ReplaceAll(word, "'", `'\''`) + "'"
+}
+
+// Executor runs commands.
+type Executor interface {
+	// Run runs cmd to completion. It stops the command when ctx is done.
+	Run(ctx context.Context, cmd Command) error
+	// LookPath finds an executable in PATH, as exec.LookPath does.
+	LookPath(file string) (string, error)
+}
+
+// Declarer is implemented by executors that track commands without being the
+// ones to carry out every change.
+type Declarer interface {
+	Declare(ctx context.Context, cmd Command) error
+}
+
+// Declare announces an action devtool carries out itself, such as fetching
+// and installing a download, described as a command. Dry runs record it like
+// any other command and plan verification refuses it when it was not planned;
+// other executors ignore it. The caller performs the action when it returns
+// nil.
+func Declare(ctx context.Context, e Executor, cmd Command) error {
+	if declarer, ok := e.(Declarer); ok {
+		return declarer.Declare(ctx, cmd)
+	}
+	return nil
+}
+
+// Output runs cmd and returns what it wrote to stdout.
+func Output(ctx context.Context, e Executor, cmd Command) ([]byte, error) {
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	err := e.Run(ctx, cmd)
+	return stdout.Bytes(), err
+}
+
+// ExitError is a command that ran and exited with a non-zero status. The real
+// executor returns *exec.ExitError instead; both have ExitCode.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+func (e *ExitError) ExitCode() int {
+	return e.Code
+}
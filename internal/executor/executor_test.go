@@ -0,0 +1,243 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCommandString(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  Command
+		want string
+	}{
+		{"plain", New("brew", "install", "jq"), "brew install jq"},
+		{"quoted", New("sh", "-c", "echo 'hi' && exit 1"), `sh -c 'echo '\''hi'\'' && exit 1'`},
+		{"empty argument", New("git", "commit", "-m", ""), "git commit -m ''"},
+		{
+			"directory and environment",
+			Command{Name: "make", Args: []string{"install"}, Dir: "/tmp/build dir", Env: []string{"PREFIX=/opt/x y", "CC=clang"}},
+			"cd '/tmp/build dir' && PREFIX='/opt/x y' CC=clang make install",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cmd.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	fake := NewFake().On("brew list --versions", Response{Stdout: "jq 1.7\n"})
+	var notified []string
+	recorder := NewRecorder(fake, func(cmd Command) { notified = append(notified, cmd.String()) })
+
+	query := New("brew", "list", "--versions")
+	query.ReadOnly = true
+	output, err := Output(context.Background(), recorder, query)
+	if err != nil {
+		t.Fatalf("read-only command: %v", err)
+	}
+	if string(output) != "jq 1.7\n" {
+		t.Errorf("read-only output = %q", output)
+	}
+
+	if err := recorder.Run(context.Background(), New("brew", "install", "jq")); err != nil {
+		t.Fatalf("recorded command: %v", err)
+	}
+
+	if calls := fake.Calls(); len(calls) != 1 || calls[0] != "brew list --versions" {
+		t.Errorf("commands run = %q, want only the read-only one", calls)
+	}
+	if got := recorder.Commands(); len(got) != 1 || got[0].String() != "brew install jq" {
+		t.Errorf("Commands() = %v", got)
+	}
+	if len(notified) != 1 || notified[0] != "brew install jq" {
+		t.Errorf("notified = %q", notified)
+	}
+}
+
+func TestFake(t *testing.T) {
+	fake := NewFake().
+		On("git rev-parse HEAD", Response{Stdout: "abc\n"}, Response{Err: &ExitError{Code: 128}}).
+		Path("git", "/usr/bin/git")
+
+	output, err := Output(context.Background(), fake, New("git", "rev-parse", "HEAD"))
+	if err != nil || string(output) != "abc\n" {
+		t.Fatalf("first call = %q, %v", output, err)
+	}
+	for i := 0; i < 2; i++ {
+		var exitErr *ExitError
+		if err := fake.Run(context.Background(), New("git", "rev-parse", "HEAD")); !errors.As(err, &exitErr) || exitErr.Code != 128 {
+			t.Errorf("call %d error = %v, want exit status 128", i+2, err)
+		}
+	}
+
+	if err := fake.Run(context.Background(), New("git", "pull")); err == nil || !strings.Contains(err.Error(), "unexpected command") {
+		t.Errorf("unscripted command error = %v", err)
+	}
+	if path, err := fake.LookPath("git"); err != nil || path != "/usr/bin/git" {
+		t.Errorf("LookPath(git) = %q, %v", path, err)
+	}
+	if _, err := fake.LookPath("hg"); err == nil {
+		t.Error("LookPath(hg) succeeded")
+	}
+	if calls := fake.Calls(); len(calls) != 4 {
+		t.Errorf("Calls() = %q, want 4 calls", calls)
+	}
+}
+
+func TestVerifier(t *testing.T) {
+	steps := [][]string{
+		{"brew update"},
+		{"brew install jq", "brew link jq"},
+		{"git clone repo", "make"},
+		{"brew cleanup"},
+	}
+
+	tests := []struct {
+		name    string
+		run     []string
+		wantErr string // the command that is refused
+		unrun   []string
+	}{
+		{
+			name: "whole plan",
+			run:  []string{"brew update", "brew install jq", "brew link jq", "git clone repo", "make", "brew cleanup"},
+		},
+		{
+			name: "retry",
+			run:  []string{"brew update", "brew install jq", "brew install jq", "brew link jq", "git clone repo", "make", "brew cleanup"},
+		},
+		{
+			name:  "step cut short by the next step",
+			run:   []string{"brew update", "brew install jq", "git clone repo", "make", "brew cleanup"},
+			unrun: []string{"brew link jq"},
+		},
+		{
+			name:  "step skipped",
+			run:   []string{"brew update", "git clone repo", "make", "brew cleanup"},
+			unrun: []string{"brew install jq", "brew link jq"},
+		},
+		{
+			name:  "stopped early",
+			run:   []string{"brew update", "brew install jq"},
+			unrun: []string{"brew link jq", "git clone repo", "make", "brew cleanup"},
+		},
+		{
+			name:    "reordered within a step",
+			run:     []string{"brew update", "brew link jq"},
+			wantErr: "brew link jq",
+		},
+		{
+			name:    "jump into the middle of a later step",
+			run:     []string{"brew update", "make"},
+			wantErr: "make",
+		},
+		{
+			name:    "going back",
+			run:     []string{"brew update", "git clone repo", "brew install jq"},
+			wantErr: "brew install jq",
+		},
+		{
+			name:    "not in the plan",
+			run:     []string{"brew update", "rm -rf /"},
+			wantErr: "rm -rf /",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := NewFake()
+			for _, step := range steps {
+				for _, line := range step {
+					fake.On(line)
+				}
+			}
+			verifier := NewVerifier(fake, steps)
+
+			for _, line := range tt.run {
+				fields := strings.Fields(line)
+				err := verifier.Run(context.Background(), New(fields[0], fields[1:]...))
+				if line == tt.wantErr {
+					var unexpected *UnexpectedCommandError
+					if !errors.As(err, &unexpected) {
+						t.Fatalf("%s: error = %v, want it refused", line, err)
+					}
+					if calls := fake.Calls(); len(calls) > 0 && calls[len(calls)-1] == line {
+						t.Fatalf("%s was run although it was refused", line)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("%s: %v", line, err)
+				}
+			}
+			if tt.wantErr != "" {
+				t.Fatalf("%s was not refused", tt.wantErr)
+			}
+
+			if got := verifier.Unrun(); strings.Join(got, "\n") != strings.Join(tt.unrun, "\n") {
+				t.Errorf("Unrun() = %q, want %q", got, tt.unrun)
+			}
+		})
+	}
+}
+
+func TestVerifierReadOnly(t *testing.T) {
+	fake := NewFake().On("brew list", Response{Stdout: "jq\n"})
+	verifier := NewVerifier(fake, nil)
+
+	query := New("brew", "list")
+	query.ReadOnly = true
+	var stdout bytes.Buffer
+	query.Stdout = &stdout
+	if err := verifier.Run(context.Background(), query); err != nil {
+		t.Fatalf("read-only command: %v", err)
+	}
+	if stdout.String() != "jq\n" {
+		t.Errorf("stdout = %q", stdout.String())
+	}
+	if err := verifier.Run(context.Background(), New("brew", "list")); err == nil {
+		t.Error("the same command without ReadOnly was run outside the plan")
+	}
+}
+
+func TestDeclare(t *testing.T) {
+	download := New("download", "https://example.com/jq.tar.gz", "sha256:abc", "--into", "/usr/local/bin")
+
+	if err := Declare(context.Background(), NewSystem(), download); err != nil {
+		t.Errorf("System: %v", err)
+	}
+
+	recorder := NewRecorder(NewFake(), nil)
+	if err := Declare(context.Background(), recorder, download); err != nil {
+		t.Fatalf("Recorder: %v", err)
+	}
+	if got := recorder.Commands(); len(got) != 1 || got[0].String() != download.String() {
+		t.Errorf("Commands() = %v", got)
+	}
+
+	fake := NewFake()
+	verifier := NewVerifier(fake, [][]string{{download.String()}})
+	if err := Declare(context.Background(), verifier, download); err != nil {
+		t.Fatalf("planned download: %v", err)
+	}
+	if calls := fake.Calls(); len(calls) != 1 || calls[0] != download.String() {
+		t.Errorf("declared = %q", calls)
+	}
+	if unrun := verifier.Unrun(); len(unrun) != 0 {
+		t.Errorf("Unrun() = %q", unrun)
+	}
+
+	other := New("download", "https://example.com/jq.tar.gz", "sha256:def", "--into", "/usr/local/bin")
+	if err := Declare(context.Background(), NewVerifier(NewFake(), [][]string{{download.String()}}), other); err == nil {
+		t.Error("a download with another checksum was admitted")
+	}
+}
@@ -0,0 +1,117 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"sync"
+)
+
+// Response is a scripted result for a Fake command.
+type Response struct {
+	Stdout string
+	Stderr string
+	// Err is returned from Run, e.g. &ExitError{Code: 1}.
+	Err error
+}
+
+// Fake answers commands from a script, for tests of code built on the
+// installer. Commands are matched by their String form. Every call is
+// recorded, including unexpected ones, which fail.
+type Fake struct {
+	mu        sync.Mutex
+	responses map[string][]Response
+	paths     map[string]string
+	calls     []Command
+}
+
+func NewFake() *Fake {
+	return &Fake{
+		responses: make(map[string][]Response),
+		paths:     make(map[string]string),
+	}
+}
+
+// On scripts the responses to a command line. Consecutive calls consume the
+// responses in order and the last one repeats; no responses means success
+// without output.
+func (f *Fake) On(commandLine string, responses ...Response) *Fake {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(responses) == 0 {
+		responses = []Response{{}}
+	}
+	f.responses[commandLine] = append(f.responses[commandLine], responses...)
+	return f
+}
+
+// Path makes LookPath find file at path.
+func (f *Fake) Path(file, path string) *Fake {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.paths[file] = path
+	return f
+}
+
+func (f *Fake) Run(ctx context.Context, cmd Command) error {
+	f.mu.Lock()
+	f.calls = append(f.calls, cmd)
+	line := cmd.String()
+	queue, ok := f.responses[line]
+	var response Response
+	if ok {
+		response = queue[0]
+		if len(queue) > 1 {
+			f.responses[line] = queue[1:]
+		}
+	}
+	f.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("unexpected command: %s", line)
+	}
+
+	if cmd.Stdout != nil && response.Stdout != "" {
+		io.WriteString(cmd.Stdout, response.Stdout)
+	}
+	if cmd.Stderr != nil && response.Stderr != "" {
+		io.WriteString(cmd.Stderr, response.Stderr)
+	}
+	return response.Err
+}
+
+// Declare records an action like a command. It succeeds unless the command
+// line was scripted with an error.
+func (f *Fake) Declare(ctx context.Context, cmd Command) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, cmd)
+	if queue, ok := f.responses[cmd.String()]; ok {
+		return queue[0].Err
+	}
+	return nil
+}
+
+func (f *Fake) LookPath(file string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if path, ok := f.paths[file]; ok {
+		return path, nil
+	}
+	return "", &exec.Error{Name: file, Err: exec.ErrNotFound}
+}
+
+// Calls returns the command lines run so far, in order.
+func (f *Fake) Calls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	lines := make([]string, len(f.calls))
+	for i, cmd := range f.calls {
+		lines[i] = cmd.String()
+	}
+	return lines
+}
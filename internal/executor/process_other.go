@@ -0,0 +1,18 @@
+//go:build !unix
+
+package executor
+
+import (
+	"os/exec"
+	"time"
+)
+
+// setProcessGroup leaves the command as is; without process groups only the
+// command itself is killed on cancellation.
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// setTerminate keeps the default kill on cancellation but stops waiting for
+// output that orphaned children hold open.
+func setTerminate(cmd *exec.Cmd) {
+	cmd.WaitDelay = 6 * time.Second
+}
@@ -0,0 +1,41 @@
+//go:build unix
+
+package executor
+
+import (
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// groupKillDelay is how long a cancelled process group gets to exit after
+// SIGTERM before it is killed.
+const groupKillDelay = 5 * time.Second
+
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		pgid := -cmd.Process.Pid
+		if err := syscall.Kill(pgid, syscall.SIGTERM); err != nil {
+			return cmd.Process.Kill()
+		}
+		time.AfterFunc(groupKillDelay, func() {
+			syscall.Kill(pgid, syscall.SIGKILL)
+		})
+		return nil
+	}
+	cmd.WaitDelay = groupKillDelay + time.Second
+}
+
+func setTerminate(cmd *exec.Cmd) {
+	cmd.Cancel = func() error {
+		if err := cmd.Process.Signal(syscall.SIGTERM); err != nil {
+			return cmd.Process.Kill()
+		}
+		time.AfterFunc(groupKillDelay, func() {
+			cmd.Process.Kill()
+		})
+		return nil
+	}
+	cmd.WaitDelay = groupKillDelay + time.Second
+}
@@ -0,0 +1,55 @@
+package executor
+
+import (
+	"context"
+	"sync"
+)
+
+// Recorder captures the commands a run would execute instead of running them.
+// Read-only commands are passed on to the next executor so a dry run still
+// sees what is installed.
+type Recorder struct {
+	next   Executor
+	notify func(Command)
+
+	mu       sync.Mutex
+	commands []Command
+}
+
+// NewRecorder returns a recorder in front of next. notify, when set, is
+// called with every recorded command.
+func NewRecorder(next Executor, notify func(Command)) *Recorder {
+	return &Recorder{next: next, notify: notify}
+}
+
+func (r *Recorder) Run(ctx context.Context, cmd Command) error {
+	if cmd.ReadOnly {
+		return r.next.Run(ctx, cmd)
+	}
+
+	r.mu.Lock()
+	r.commands = append(r.commands, cmd)
+	r.mu.Unlock()
+
+	if r.notify != nil {
+		r.notify(cmd)
+	}
+	return nil
+}
+
+// Declare records an action devtool would carry out itself.
+func (r *Recorder) Declare(ctx context.Context, cmd Command) error {
+	cmd.ReadOnly = false
+	return r.Run(ctx, cmd)
+}
+
+func (r *Recorder) LookPath(file string) (string, error) {
+	return r.next.LookPath(file)
+}
+
+// Commands returns the recorded commands in the order they were run.
+func (r *Recorder) Commands() []Command {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Command{}, r.commands...)
+}
@@ -0,0 +1,46 @@
+package executor
+
+import (
+	"context"
+	"os"
+	"os/exec"
+)
+
+// System runs commands on this machine.
+type System struct{}
+
+func NewSystem() *System {
+	return &System{}
+}
+
+// Run starts cmd bound to ctx. Non-interactive commands run in their own
+// process group, and the whole group is stopped when ctx is cancelled, so
+// children such as the compilers make spawns do not outlive devtool.
+// Interactive commands stay in devtool's process group so they can read the
+// terminal; on a timeout only the command itself can be stopped, so its
+// output is abandoned if children keep it open.
+func (s *System) Run(ctx context.Context, c Command) error {
+	cmd := exec.CommandContext(ctx, c.Name, c.Args...)
+	cmd.Dir = c.Dir
+	if len(c.Env) > 0 {
+		cmd.Env = append(os.Environ(), c.Env...)
+	}
+	cmd.Stdin = c.Stdin
+	cmd.Stdout = c.Stdout
+	cmd.Stderr = c.Stderr
+
+	if c.Interactive {
+		if cmd.Stdin == nil {
+			cmd.Stdin = os.Stdin
+		}
+		setTerminate(cmd)
+	} else {
+		setProcessGroup(cmd)
+	}
+
+	return cmd.Run()
+}
+
+func (s *System) LookPath(file string) (string, error) {
+	return exec.LookPath(file)
+}
@@ -0,0 +1,112 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Verifier only runs the commands it was told to expect, in order, so an
+// approved plan cannot grow or be reordered at execution time. Expected
+// commands come in steps, such as the commands installing one tool. Within a
+// step every command must be the next one or repeat the previous one (a
+// retry). A step may stop early, when a tool turns out to need less than
+// planned, and a later step may be skipped entirely, but only by starting the
+// first command of a following step. Read-only commands are always run;
+// anything else is refused without running.
+type Verifier struct {
+	next Executor
+
+	mu    sync.Mutex
+	steps [][]string
+	step  int // the step being run
+	pos   int // the next command of that step
+	last  string
+	unrun []string
+}
+
+func NewVerifier(next Executor, steps [][]string) *Verifier {
+	return &Verifier{next: next, steps: steps}
+}
+
+// UnexpectedCommandError is returned for a command the plan does not contain
+// at this point.
+type UnexpectedCommandError struct {
+	Command string
+}
+
+func (e *UnexpectedCommandError) Error() string {
+	return fmt.Sprintf("refusing to run %q: it is not the next command of the plan", e.Command)
+}
+
+func (v *Verifier) Run(ctx context.Context, cmd Command) error {
+	if cmd.ReadOnly {
+		return v.next.Run(ctx, cmd)
+	}
+
+	if err := v.admit(cmd.String()); err != nil {
+		return err
+	}
+	return v.next.Run(ctx, cmd)
+}
+
+func (v *Verifier) admit(line string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.last != "" && line == v.last {
+		return nil
+	}
+	if v.step < len(v.steps) && v.pos < len(v.steps[v.step]) && v.steps[v.step][v.pos] == line {
+		v.pos++
+		v.last = line
+		return nil
+	}
+
+	// Only the start of a later step may cut the current one short
+	first := v.step + 1
+	if v.pos == 0 {
+		first = v.step
+	}
+	for i := first; i < len(v.steps); i++ {
+		if len(v.steps[i]) == 0 || v.steps[i][0] != line {
+			continue
+		}
+		for ; v.step < i; v.step, v.pos = v.step+1, 0 {
+			v.unrun = append(v.unrun, v.steps[v.step][v.pos:]...)
+		}
+		v.pos = 1
+		v.last = line
+		return nil
+	}
+	return &UnexpectedCommandError{Command: line}
+}
+
+// Declare admits an action devtool carries out itself when it is the next one
+// of the plan.
+func (v *Verifier) Declare(ctx context.Context, cmd Command) error {
+	if err := v.admit(cmd.String()); err != nil {
+		return err
+	}
+	return Declare(ctx, v.next, cmd)
+}
+
+func (v *Verifier) LookPath(file string) (string, error) {
+	return v.next.LookPath(file)
+}
+
+// Unrun returns the expected commands that have not run, in plan order.
+func (v *Verifier) Unrun() []string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	unrun := append([]string{}, v.unrun...)
+	for i := v.step; i < len(v.steps); i++ {
+		if i == v.step {
+			unrun = append(unrun, v.steps[i][v.pos:]...)
+		} else {
+			unrun = append(unrun, v.steps[i]...)
+		}
+	}
+	return unrun
+}
@@ -0,0 +1,48 @@
+package installer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/lukeberry99/devtool/internal/executor"
+	"github.com/lukeberry99/devtool/internal/ui"
+)
+
+type AptManager struct {
+	nativeManager
+}
+
+func NewAptManager(logger *ui.Logger, exec executor.Executor) *AptManager {
+	manager := &AptManager{nativeManager: newNativeManager("apt", "apt-get", logger, exec)}
+	manager.refreshArgs = []string{"update"}
+	manager.installArgs = []string{"install", "-y"}
+	manager.removeArgs = []string{"remove", "-y"}
+	manager.cleanupArgs = []string{"autoclean"}
+	manager.env = []string{"DEBIAN_FRONTEND=noninteractive"}
+	return manager
+}
+
+func (a *AptManager) WithLogger(logger *ui.Logger) PackageManager {
+	clone := *a
+	clone.logger = logger
+	return &clone
+}
+
+func (a *AptManager) IsPackageInstalled(ctx context.Context, pkg string) bool {
+	output, err := a.query(ctx, "dpkg-query", "-W", "-f=${Status}", pkg)
+	return err == nil && strings.Contains(string(output), "install ok installed")
+}
+
+func (a *AptManager) GetInstalledVersion(ctx context.Context, pkg string) (string, error) {
+	output, err := a.query(ctx, "dpkg-query", "-W", "-f=${Version}", pkg)
+	if err != nil {
+		return "", fmt.Errorf("failed to get version for %s: %w", pkg, err)
+	}
+
+	version := strings.TrimSpace(string(output))
+	if version == "" {
+		return "", fmt.Errorf("%s is not installed", pkg)
+	}
+	return version, nil
+}
@@ -0,0 +1,354 @@
+package installer
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/bzip2"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/lukeberry99/devtool/internal/executor"
+)
+
+// detectArchiveFormat infers the archive format from a file name or URL.
+func detectArchiveFormat(name string) string {
+	name = strings.ToLower(name)
+	if i := strings.IndexAny(name, "?#"); i >= 0 {
+		name = name[:i]
+	}
+
+	switch {
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return "tar.gz"
+	case strings.HasSuffix(name, ".tar.xz"), strings.HasSuffix(name, ".txz"):
+		return "tar.xz"
+	case strings.HasSuffix(name, ".tar.bz2"), strings.HasSuffix(name, ".tbz2"):
+		return "tar.bz2"
+	case strings.HasSuffix(name, ".tar"):
+		return "tar"
+	case strings.HasSuffix(name, ".zip"):
+		return "zip"
+	default:
+		return "binary"
+	}
+}
+
+// extractArchive unpacks archivePath into destDir, dropping the first strip
+// path components of every entry. Raw binaries are copied in unchanged under
+// binaryName.
+func extractArchive(ctx context.Context, e executor.Executor, archivePath, destDir, format string, strip int, binaryName string) error {
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return fmt.Errorf("failed to create extraction directory: %w", err)
+	}
+
+	switch format {
+	case "tar.gz":
+		file, err := os.Open(archivePath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return fmt.Errorf("failed to read gzip stream: %w", err)
+		}
+		defer gz.Close()
+
+		return extractTar(gz, destDir, strip)
+	case "tar.bz2":
+		file, err := os.Open(archivePath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		return extractTar(bzip2.NewReader(file), destDir, strip)
+	case "tar":
+		file, err := os.Open(archivePath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		return extractTar(file, destDir, strip)
+	case "tar.xz":
+		// The standard library has no xz decoder, so stream through xz(1)
+		stdout, writer := io.Pipe()
+		cmd := executor.New("xz", "--decompress", "--stdout", archivePath)
+		cmd.Stdout = writer
+		cmd.ReadOnly = true
+		done := make(chan error, 1)
+		go func() {
+			err := e.Run(ctx, cmd)
+			writer.CloseWithError(err)
+			done <- err
+		}()
+
+		extractErr := extractTar(stdout, destDir, strip)
+		// The tar reader stops at the end-of-archive marker, and xz may have
+		// padding left to write; drain it so xz can exit before we wait on it
+		io.Copy(io.Discard, stdout)
+		if err := <-done; err != nil && extractErr == nil {
+			return fmt.Errorf("xz failed (is it installed?): %w", err)
+		}
+		return extractErr
+	case "zip":
+		return extractZip(archivePath, destDir, strip)
+	case "binary":
+		return copyExecutable(archivePath, filepath.Join(destDir, binaryName))
+	default:
+		return fmt.Errorf("unsupported archive format: %s", format)
+	}
+}
+
+func extractTar(r io.Reader, destDir string, strip int) error {
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read tar archive: %w", err)
+		}
+
+		target, ok, err := archiveTarget(destDir, header.Name, strip)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		if err := checkArchiveParents(destDir, target); err != nil {
+			return err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := writeArchiveFile(target, tr, os.FileMode(header.Mode).Perm()); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err := checkArchiveLink(destDir, target, header.Linkname); err != nil {
+				return err
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			// Swapping a path for a symlink would change where the links
+			// checked before it resolve
+			if _, err := os.Lstat(target); err == nil {
+				return fmt.Errorf("archive symlink %s replaces an entry extracted before it", target)
+			}
+			if err := os.Symlink(header.Linkname, target); err != nil {
+				return fmt.Errorf("failed to create symlink %s: %w", target, err)
+			}
+		}
+	}
+}
+
+func extractZip(archivePath, destDir string, strip int) error {
+	zr, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to open zip archive: %w", err)
+	}
+	defer zr.Close()
+
+	for _, file := range zr.File {
+		target, ok, err := archiveTarget(destDir, file.Name, strip)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		if err := checkArchiveParents(destDir, target); err != nil {
+			return err
+		}
+
+		if file.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+
+		rc, err := file.Open()
+		if err != nil {
+			return fmt.Errorf("failed to read %s from zip: %w", file.Name, err)
+		}
+		mode := file.Mode().Perm()
+		if mode == 0 {
+			mode = 0644
+		}
+		err = writeArchiveFile(target, rc, mode)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// archiveTarget maps an archive entry to its extraction path. It reports false
+// for entries consumed entirely by strip, and rejects entries that would
+// escape destDir.
+func archiveTarget(destDir, name string, strip int) (string, bool, error) {
+	parts := strings.Split(strings.Trim(path.Clean("/"+name), "/"), "/")
+	if len(parts) <= strip || (len(parts) == 1 && parts[0] == "") {
+		return "", false, nil
+	}
+
+	rel := filepath.FromSlash(strings.Join(parts[strip:], "/"))
+	target := filepath.Join(destDir, rel)
+	if !strings.HasPrefix(target, filepath.Clean(destDir)+string(os.PathSeparator)) {
+		return "", false, fmt.Errorf("archive entry %s escapes the extraction directory", name)
+	}
+
+	return target, true, nil
+}
+
+// checkArchiveParents refuses entries whose path runs through a symlink
+// extracted earlier, which would write outside the extracted tree.
+func checkArchiveParents(destDir, target string) error {
+	rel, err := filepath.Rel(destDir, filepath.Dir(target))
+	if err != nil || rel == "." {
+		return err
+	}
+
+	dir := destDir
+	for _, part := range strings.Split(rel, string(os.PathSeparator)) {
+		dir = filepath.Join(dir, part)
+		info, err := os.Lstat(dir)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("archive entry %s would be written through the symlink %s", target, dir)
+		}
+	}
+	return nil
+}
+
+// checkArchiveLink rejects a symlink entry at target whose link is absolute or
+// resolves outside destDir, following the symlinks already extracted. A link
+// may not climb out of a path that does not exist yet, since a later entry
+// could make that path a symlink.
+func checkArchiveLink(destDir, target, linkname string) error {
+	if linkname == "" || path.IsAbs(linkname) || filepath.IsAbs(linkname) || filepath.VolumeName(linkname) != "" {
+		return fmt.Errorf("archive symlink %s points to %q, outside the extraction directory", target, linkname)
+	}
+	if _, err := resolveArchiveLink(filepath.Clean(destDir), filepath.Dir(target), linkname, 0); err != nil {
+		return fmt.Errorf("archive symlink %s points to %q: %w", target, linkname, err)
+	}
+	return nil
+}
+
+func resolveArchiveLink(root, dir, linkname string, depth int) (string, error) {
+	if depth > 40 {
+		return "", fmt.Errorf("too many levels of symbolic links")
+	}
+
+	current, missing := dir, false
+	for _, part := range strings.Split(filepath.ToSlash(linkname), "/") {
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			if missing {
+				return "", fmt.Errorf("it climbs out of %s, which is not extracted yet", current)
+			}
+			current = filepath.Dir(current)
+		default:
+			next := filepath.Join(current, part)
+			if _, err := os.Lstat(next); err != nil {
+				missing = true
+			} else if link, err := os.Readlink(next); err == nil {
+				if filepath.IsAbs(link) {
+					return "", fmt.Errorf("it runs through the absolute symlink %s", next)
+				}
+				if next, err = resolveArchiveLink(root, current, link, depth+1); err != nil {
+					return "", err
+				}
+			}
+			current = next
+		}
+		if current != root && !strings.HasPrefix(current, root+string(os.PathSeparator)) {
+			return "", fmt.Errorf("it resolves outside the extraction directory")
+		}
+	}
+	return current, nil
+}
+
+func writeArchiveFile(target string, r io.Reader, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	// Replace a symlink extracted earlier rather than write through it
+	if info, err := os.Lstat(target); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		if err := os.Remove(target); err != nil {
+			return err
+		}
+	}
+
+	file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", target, err)
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(file, r); err != nil {
+		return fmt.Errorf("failed to write %s: %w", target, err)
+	}
+	return nil
+}
+
+// copyExecutable copies source to target with 0755 permissions, writing to a
+// temporary file in the target directory first so the swap is atomic.
+func copyExecutable(source, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", target, err)
+	}
+
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), "."+filepath.Base(target)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, in); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to copy %s: %w", source, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0755); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), target)
+}
@@ -0,0 +1,207 @@
+package installer
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lukeberry99/devtool/internal/executor"
+)
+
+func TestExtractArchiveFixtures(t *testing.T) {
+	tests := []struct {
+		file   string
+		format string
+	}{
+		{"hello.tar.gz", "tar.gz"},
+		{"hello.tar.xz", "tar.xz"},
+		{"hello.zip", "zip"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			if tt.format == "tar.xz" {
+				if _, err := exec.LookPath("xz"); err != nil {
+					t.Skip("xz is not installed")
+				}
+			}
+			if got := detectArchiveFormat(tt.file); got != tt.format {
+				t.Fatalf("detectArchiveFormat(%q) = %q, want %q", tt.file, got, tt.format)
+			}
+
+			dest := t.TempDir()
+			done := make(chan error, 1)
+			go func() {
+				done <- extractArchive(context.Background(), executor.NewSystem(), filepath.Join("testdata", tt.file), dest, tt.format, 1, "hello")
+			}()
+			select {
+			case err := <-done:
+				if err != nil {
+					t.Fatalf("extractArchive: %v", err)
+				}
+			case <-time.After(10 * time.Second):
+				t.Fatal("extractArchive did not return")
+			}
+
+			hello, err := os.ReadFile(filepath.Join(dest, "bin", "hello"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(hello), "echo hello") {
+				t.Errorf("bin/hello = %q", hello)
+			}
+			info, err := os.Stat(filepath.Join(dest, "bin", "hello"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Mode().Perm()&0100 == 0 {
+				t.Errorf("bin/hello mode = %v, want executable", info.Mode())
+			}
+			if _, err := os.Stat(filepath.Join(dest, "README")); err != nil {
+				t.Errorf("README: %v", err)
+			}
+		})
+	}
+}
+
+type tarEntry struct {
+	name     string
+	typeflag byte
+	linkname string
+	body     string
+}
+
+func writeTar(t *testing.T, entries ...tarEntry) string {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, entry := range entries {
+		header := &tar.Header{Name: entry.name, Typeflag: entry.typeflag, Linkname: entry.linkname, Mode: 0755, Size: int64(len(entry.body))}
+		if entry.typeflag != tar.TypeReg {
+			header.Size = 0
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if entry.typeflag == tar.TypeReg {
+			if _, err := tw.Write([]byte(entry.body)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	archive := filepath.Join(t.TempDir(), "archive.tar")
+	if err := os.WriteFile(archive, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return archive
+}
+
+func TestExtractArchiveSymlinks(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []tarEntry
+		wantErr string
+	}{
+		{
+			name: "relative link inside",
+			entries: []tarEntry{
+				{name: "pkg/bin/tool", typeflag: tar.TypeReg, body: "tool"},
+				{name: "pkg/tool", typeflag: tar.TypeSymlink, linkname: "bin/tool"},
+			},
+		},
+		{
+			name: "absolute link",
+			entries: []tarEntry{
+				{name: "pkg/evil", typeflag: tar.TypeSymlink, linkname: "{outside}"},
+			},
+			wantErr: "outside the extraction directory",
+		},
+		{
+			name: "write through a link",
+			entries: []tarEntry{
+				{name: "pkg/evil", typeflag: tar.TypeSymlink, linkname: "."},
+				{name: "pkg/evil/pwned", typeflag: tar.TypeReg, body: "pwned"},
+			},
+			wantErr: "written through the symlink",
+		},
+		{
+			name: "relative link escaping",
+			entries: []tarEntry{
+				{name: "pkg/evil", typeflag: tar.TypeSymlink, linkname: "../../escape"},
+			},
+			wantErr: "resolves outside",
+		},
+		{
+			name: "escape through an earlier link",
+			entries: []tarEntry{
+				{name: "pkg/up", typeflag: tar.TypeSymlink, linkname: ".."},
+				{name: "pkg/evil", typeflag: tar.TypeSymlink, linkname: "up/../escape"},
+			},
+			wantErr: "resolves outside",
+		},
+		{
+			name: "escape through a later link",
+			entries: []tarEntry{
+				{name: "pkg/evil", typeflag: tar.TypeSymlink, linkname: "up/../../escape"},
+				{name: "pkg/up", typeflag: tar.TypeSymlink, linkname: ".."},
+			},
+			wantErr: "not extracted yet",
+		},
+		{
+			name: "link replacing a directory",
+			entries: []tarEntry{
+				{name: "pkg/dir/", typeflag: tar.TypeDir},
+				{name: "pkg/evil", typeflag: tar.TypeSymlink, linkname: "dir/../x"},
+				{name: "pkg/dir", typeflag: tar.TypeSymlink, linkname: ".."},
+			},
+			wantErr: "replaces an entry",
+		},
+		{
+			name: "dot-dot entry name stays inside",
+			entries: []tarEntry{
+				{name: "../../pwned", typeflag: tar.TypeReg, body: "pwned"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			outside := filepath.Join(root, "outside")
+			if err := os.Mkdir(outside, 0755); err != nil {
+				t.Fatal(err)
+			}
+			for i := range tt.entries {
+				tt.entries[i].linkname = strings.ReplaceAll(tt.entries[i].linkname, "{outside}", outside)
+			}
+
+			dest := filepath.Join(root, "dest", "extract")
+			err := extractArchive(context.Background(), executor.NewFake(), writeTar(t, tt.entries...), dest, "tar", 0, "tool")
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("extractArchive: %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("extractArchive error = %v, want %q", err, tt.wantErr)
+			}
+
+			leaked, _ := filepath.Glob(filepath.Join(root, "dest", "*"))
+			if len(leaked) != 1 {
+				t.Errorf("files written outside the extraction directory: %v", leaked)
+			}
+			if entries, _ := os.ReadDir(outside); len(entries) > 0 {
+				t.Errorf("files written to %s: %v", outside, entries)
+			}
+		})
+	}
+}
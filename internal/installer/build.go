@@ -0,0 +1,419 @@
+package installer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/lukeberry99/devtool/internal/config"
+	"github.com/lukeberry99/devtool/internal/executor"
+	"github.com/lukeberry99/devtool/internal/state"
+	"github.com/lukeberry99/devtool/internal/ui"
+)
+
+// workspaceLocks holds one mutex per workspace directory, shared by every
+// runner in the process.
+var workspaceLocks sync.Map
+
+// buildWorkspace is the checkout a tool is built in. Every git and shell
+// command runs with its working directory set to the workspace, never by
+// changing the process directory, so builds of different tools can run at the
+// same time. Each tool gets its own directory; operations on one directory are
+// serialized.
+type buildWorkspace struct {
+	name     string
+	dir      string
+	logger   *ui.Logger
+	executor executor.Executor
+	retry    retryPolicy
+	// env is added to the environment of build steps
+	env []string
+}
+
+func newBuildWorkspace(name string, logger *ui.Logger, exec executor.Executor, retry retryPolicy) *buildWorkspace {
+	return &buildWorkspace{
+		name:     name,
+		dir:      buildRepoPath(name),
+		logger:   logger,
+		executor: exec,
+		retry:    retry,
+	}
+}
+
+// lock claims the workspace and returns the function that releases it.
+func (w *buildWorkspace) lock() func() {
+	mu, _ := workspaceLocks.LoadOrStore(w.dir, &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+	return mu.(*sync.Mutex).Unlock
+}
+
+func (w *buildWorkspace) exists() bool {
+	_, err := os.Stat(filepath.Join(w.dir, ".git"))
+	return err == nil
+}
+
+func (w *buildWorkspace) git(args ...string) executor.Command {
+	cmd := executor.New("git", args...)
+	cmd.Dir = w.dir
+	return cmd
+}
+
+func (w *buildWorkspace) clone(repository string) executor.Command {
+	return executor.New("git", "clone", repository, w.dir)
+}
+
+// step builds the command for a shell step run in the workspace.
+func (w *buildWorkspace) step(step string, interactive bool) executor.Command {
+	cmd := executor.New("sh", "-c", step)
+	cmd.Interactive = interactive
+	cmd.Dir = w.dir
+	cmd.Env = w.env
+	return cmd
+}
+
+// preview passes the commands of a build to a dry-run recorder without
+// touching the workspace. Whether the commit was built already is only known
+// once the source is fetched, so every step is listed.
+func (w *buildWorkspace) preview(ctx context.Context, buildConfig *config.BuildConfig, version string) error {
+	var commands []executor.Command
+	if w.exists() {
+		commands = append(commands, w.git("fetch", "origin"))
+	} else {
+		commands = append(commands, w.clone(buildConfig.Repository))
+	}
+
+	target := buildTarget(version)
+	commands = append(commands, w.git("checkout", target))
+	if target == "master" || target == "stable" {
+		commands = append(commands, w.git("pull", "origin", target))
+	}
+
+	if len(buildConfig.BuildSteps) > 0 {
+		clean := executor.New("make", "distclean")
+		clean.Dir = w.dir
+		commands = append(commands, clean)
+	}
+	for _, step := range buildConfig.BuildSteps {
+		commands = append(commands, w.step(step, false))
+	}
+	for _, step := range buildConfig.InstallSteps {
+		commands = append(commands, w.step(step, true))
+	}
+
+	for _, cmd := range commands {
+		if err := w.executor.Run(ctx, cmd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// prepare clones the repository, or fetches it when already present, and
+// checks out the ref for version.
+func (w *buildWorkspace) prepare(ctx context.Context, repository, version string) error {
+	w.logger.Info(fmt.Sprintf("Preparing %s repository...", w.name))
+
+	if err := os.MkdirAll(filepath.Dir(w.dir), 0755); err != nil {
+		return fmt.Errorf("failed to create build directory: %w", err)
+	}
+
+	if w.exists() {
+		w.logger.Debug("Repository exists, updating...")
+		fetch := func() executor.Command { return w.git("fetch", "origin") }
+		if err := w.retry.run(ctx, w.logger, w.executor, "git fetch", fetch); err != nil {
+			return fmt.Errorf("failed to fetch updates: %w", err)
+		}
+		return w.checkout(ctx, version)
+	}
+
+	w.logger.Info(fmt.Sprintf("Cloning %s repository...", w.name))
+	stdout, stderr := w.logger.Writer(), w.logger.ErrorWriter()
+	defer stdout.Close()
+	defer stderr.Close()
+
+	err := w.retry.run(ctx, w.logger, w.executor, "git clone "+repository, func() executor.Command {
+		cmd := w.clone(repository)
+		cmd.Stdout = stdout
+		cmd.Stderr = stderr
+		return cmd
+	})
+	if err != nil {
+		return fmt.Errorf("failed to clone repository: %w", err)
+	}
+
+	return w.checkout(ctx, version)
+}
+
+func (w *buildWorkspace) checkout(ctx context.Context, version string) error {
+	target := buildTarget(version)
+
+	w.logger.Debug(fmt.Sprintf("Checking out %s", target))
+	if err := runStep(ctx, w.executor, w.git("checkout", target)); err != nil {
+		return fmt.Errorf("failed to checkout %s: %w", target, err)
+	}
+
+	// Pull latest changes if on a branch
+	if target == "master" || target == "stable" {
+		pull := func() executor.Command { return w.git("pull", "origin", target) }
+		if err := w.retry.run(ctx, w.logger, w.executor, "git pull", pull); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			w.logger.Warn(fmt.Sprintf("Failed to pull latest changes: %v", err))
+		}
+	}
+
+	return nil
+}
+
+// head returns the commit checked out in the workspace.
+func (w *buildWorkspace) head(ctx context.Context) (string, error) {
+	return headCommit(ctx, w.executor, w.dir)
+}
+
+// runSteps runs shell steps in the workspace, each under the step timeout.
+// Steps that may prompt for a sudo password keep the terminal.
+func (w *buildWorkspace) runSteps(ctx context.Context, kind string, steps []string, interactive bool) error {
+	stdout, stderr := w.logger.Writer(), w.logger.ErrorWriter()
+	defer stdout.Close()
+	defer stderr.Close()
+
+	for i, step := range steps {
+		w.logger.Info(fmt.Sprintf("Executing %s step %d/%d: %s", kind, i+1, len(steps), step))
+
+		cmd := w.step(step, interactive)
+		cmd.Stdout = stdout
+		cmd.Stderr = stderr
+		if err := runStep(ctx, w.executor, cmd); err != nil {
+			return fmt.Errorf("%s step %d failed: %w", kind, i+1, err)
+		}
+	}
+
+	return nil
+}
+
+func (r *ToolRunner) buildFromSource(ctx context.Context, name string, toolConfig config.ToolConfig) error {
+	r.logger.Info(fmt.Sprintf("Building %s from source", name))
+
+	if toolConfig.BuildConfig == nil {
+		return fmt.Errorf("build configuration is required for source builds")
+	}
+
+	buildConfig := toolConfig.BuildConfig
+
+	// A rolled-back tool stays on the build it was rolled back to until the
+	// user asks to move on
+	previous, _ := r.builtStatus(name)
+	if previous.RolledBack && !r.force && !r.rebuild && prefixIntact(previous.Prefix) {
+		r.logger.Warn(fmt.Sprintf("%s was rolled back to %s; keeping it (run devtool upgrade %s or install --rebuild to build again)", name, filepath.Base(previous.Prefix), name))
+		return nil
+	}
+
+	r.logger.Info(fmt.Sprintf("[BUILD] Building %s from %s", name, buildConfig.Repository))
+
+	// 1. Install dependencies if specified
+	if len(buildConfig.Dependencies) > 0 {
+		if err := r.InstallDependencies(ctx, buildConfig.Dependencies); err != nil {
+			return fmt.Errorf("failed to install dependencies for %s: %w", name, err)
+		}
+	}
+
+	workspace := newBuildWorkspace(name, r.logger, r.executor, r.retry)
+	if r.dryRun {
+		return workspace.preview(ctx, buildConfig, toolConfig.Version)
+	}
+	unlock := workspace.lock()
+	defer unlock()
+
+	// 2. Prepare repository
+	if err := workspace.prepare(ctx, buildConfig.Repository, toolConfig.Version); err != nil {
+		return fmt.Errorf("failed to prepare repository for %s: %w", name, err)
+	}
+
+	// 3. Skip the build when this commit was already built with these steps
+	commit, err := workspace.head(ctx)
+	if err != nil {
+		return err
+	}
+	buildHash := buildStepsHash(buildConfig)
+
+	if !r.rebuild && previous.Installed && previous.Source == "built_from_source" &&
+		previous.Commit == commit && previous.BuildHash == buildHash && prefixIntact(previous.Prefix) {
+		r.logger.Success(fmt.Sprintf("%s is already built at %s, skipping build (use --rebuild to force)", name, shortSHA(commit)))
+		return nil
+	}
+
+	// A retained build of this commit (e.g. after a rollback) is switched to
+	// rather than rebuilt
+	if !r.rebuild {
+		for _, entry := range previous.Prefixes {
+			if entry.Commit == commit && entry.BuildHash == buildHash && prefixIntact(entry.Path) {
+				previous.RolledBack = false
+				return r.switchPrefix(name, buildConfig, previous, entry)
+			}
+		}
+	}
+
+	// Steps install into a fresh prefix through $PREFIX
+	prefix := newPrefix(name, toolConfig.Version, commit)
+	if err := os.MkdirAll(prefix, 0755); err != nil {
+		return fmt.Errorf("failed to create install prefix: %w", err)
+	}
+	workspace.env = []string{"PREFIX=" + prefix}
+	installed := false
+	defer func() {
+		if !installed {
+			os.RemoveAll(prefix)
+		}
+	}()
+
+	// 4. Execute build steps
+	if len(buildConfig.BuildSteps) > 0 {
+		r.logger.Info(fmt.Sprintf("Building %s...", name))
+
+		// Clean previous builds if make is involved
+		clean := executor.New("make", "distclean")
+		clean.Dir = workspace.dir
+		r.executor.Run(ctx, clean) // Ignore errors for clean
+
+		if err := workspace.runSteps(ctx, "build", buildConfig.BuildSteps, false); err != nil {
+			return fmt.Errorf("failed to build %s: %w", name, err)
+		}
+	} else {
+		r.logger.Debug(fmt.Sprintf("No build steps defined for %s", name))
+	}
+
+	// 5. Execute install steps
+	if len(buildConfig.InstallSteps) > 0 {
+		r.logger.Info(fmt.Sprintf("Installing %s...", name))
+		if err := workspace.runSteps(ctx, "install", buildConfig.InstallSteps, true); err != nil {
+			return fmt.Errorf("failed to install %s: %w", name, err)
+		}
+	} else {
+		r.logger.Debug(fmt.Sprintf("No install steps defined for %s", name))
+	}
+
+	// 6. Switch to the new prefix. Steps that ignored $PREFIX installed
+	// elsewhere; keep the old behaviour for them, without rollback.
+	var links []string
+	managed := prefixPopulated(prefix)
+	if managed {
+		var err error
+		if links, err = r.activatePrefix(name, prefix, buildBinDir(buildConfig), previous.Files); err != nil {
+			// Point current back at the previous build before the new
+			// prefix is removed
+			if previous.Prefix != "" && prefixIntact(previous.Prefix) {
+				switchSymlink(previous.Prefix, currentLink(name))
+			}
+			return err
+		}
+		installed = true
+		r.recordPrefixUndo(name, prefix, buildBinDir(buildConfig), previous, links, true)
+	} else {
+		r.logger.Warn(fmt.Sprintf("Install steps for %s did not use $PREFIX; rollback is not available", name))
+		os.Remove(prefix)
+	}
+
+	// Update state tracking, remembering installed files when the build
+	// system recorded them
+	r.updateToolState(ctx, name, toolConfig, "built_from_source", func(status *state.ToolStatus) {
+		status.Commit = commit
+		status.BuildHash = buildHash
+		if !managed {
+			status.Files = readInstallManifest(workspace.dir)
+			return
+		}
+
+		status.Files = links
+		status.Prefix = prefix
+		history := append(previous.Prefixes, state.InstallPrefix{
+			Path:        prefix,
+			Version:     status.Version,
+			Commit:      commit,
+			BuildHash:   buildHash,
+			InstalledAt: status.InstallDate,
+		})
+		// Atomic runs keep the previous build so they can switch back to it
+		keep := []string{prefix}
+		if r.tx != nil {
+			keep = append(keep, previous.Prefix)
+		}
+		status.Prefixes = r.prunePrefixes(history, buildConfig.Retain, keep...)
+	})
+
+	r.logger.Info(fmt.Sprintf("✅ %s built and installed successfully", name))
+	return nil
+}
+
+func (r *ToolRunner) builtStatus(name string) (state.ToolStatus, bool) {
+	if r.stateManager == nil {
+		return state.ToolStatus{}, false
+	}
+	return r.stateManager.GetToolStatus(name)
+}
+
+// headCommit returns the commit checked out in a repository.
+func headCommit(ctx context.Context, e executor.Executor, repoDir string) (string, error) {
+	cmd := executor.New("git", "rev-parse", "HEAD")
+	cmd.Dir = repoDir
+	cmd.ReadOnly = true
+	output, err := executor.Output(ctx, e, cmd)
+	if err != nil {
+		return "", fmt.Errorf("failed to read checked-out commit in %s: %w", repoDir, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// buildStepsHash fingerprints everything that affects a build besides the
+// source itself, so editing the steps triggers a rebuild.
+func buildStepsHash(buildConfig *config.BuildConfig) string {
+	h := sha256.New()
+	for _, part := range [][]string{{buildConfig.Repository}, buildConfig.Dependencies, buildConfig.BuildSteps, buildConfig.InstallSteps} {
+		for _, line := range part {
+			fmt.Fprintf(h, "%s\n", line)
+		}
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// buildTarget maps a configured version to the git ref that is checked out.
+func buildTarget(version string) string {
+	switch version {
+	case "nightly", "":
+		return "master"
+	case "stable":
+		return "stable"
+	default:
+		return version
+	}
+}
+
+func buildRepoPath(name string) string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".devtool", "builds", name, name)
+}
+
+// readInstallManifest returns the files listed in a CMake install_manifest.txt,
+// which CMake writes either in the source tree or in its build directory.
+func readInstallManifest(repoDir string) []string {
+	for _, candidate := range []string{"install_manifest.txt", filepath.Join("build", "install_manifest.txt")} {
+		data, err := os.ReadFile(filepath.Join(repoDir, candidate))
+		if err != nil {
+			continue
+		}
+		var files []string
+		for _, line := range strings.Split(string(data), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				files = append(files, line)
+			}
+		}
+		return files
+	}
+	return nil
+}
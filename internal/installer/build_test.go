@@ -0,0 +1,48 @@
+package installer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lukeberry99/devtool/internal/config"
+	"github.com/lukeberry99/devtool/internal/executor"
+	"github.com/lukeberry99/devtool/internal/state"
+)
+
+func TestRolledBackBuildIsKept(t *testing.T) {
+	fake := executor.NewFake()
+	runner, stateManager := newTestRunner(t, fake, Options{})
+
+	prefix := filepath.Join(optDir("neovim"), "abc1234")
+	if err := os.MkdirAll(filepath.Join(prefix, "bin"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	stateManager.UpdateToolStatus("neovim", state.ToolStatus{
+		Installed:  true,
+		Version:    "0.10.0-dev",
+		Source:     "built_from_source",
+		Commit:     "abc1234",
+		Prefix:     prefix,
+		Prefixes:   []state.InstallPrefix{{Path: prefix, Commit: "abc1234"}},
+		RolledBack: true,
+	})
+
+	toolConfig := config.ToolConfig{
+		Source:      "build",
+		Version:     "nightly",
+		BuildConfig: &config.BuildConfig{Repository: "https://github.com/neovim/neovim.git"},
+		Enabled:     true,
+	}
+	// The fake fails every command, so any fetch or build fails the install
+	if err := runner.InstallTool(context.Background(), "neovim", toolConfig); err != nil {
+		t.Fatalf("InstallTool: %v", err)
+	}
+	if calls := fake.Calls(); len(calls) > 0 {
+		t.Errorf("a rolled-back build ran %q", calls)
+	}
+	if status, _ := stateManager.GetToolStatus("neovim"); !status.RolledBack || status.Prefix != prefix {
+		t.Errorf("state = %+v, want the rolled-back build kept", status)
+	}
+}
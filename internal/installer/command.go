@@ -0,0 +1,68 @@
+package installer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lukeberry99/devtool/internal/executor"
+)
+
+type stepTimeoutKey struct{}
+
+// withStepTimeout bounds every command started under ctx by timeout. Zero
+// means no limit.
+func withStepTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, stepTimeoutKey{}, timeout)
+}
+
+func stepTimeout(ctx context.Context) time.Duration {
+	timeout, _ := ctx.Value(stepTimeoutKey{}).(time.Duration)
+	return timeout
+}
+
+// stepContext derives the context for a single command.
+func stepContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if timeout := stepTimeout(ctx); timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// runStep runs cmd under the step timeout carried in ctx.
+func runStep(ctx context.Context, e executor.Executor, cmd executor.Command) error {
+	stepCtx, cancel := stepContext(ctx)
+	defer cancel()
+
+	err := e.Run(stepCtx, cmd)
+	return stepError(ctx, stepCtx, err)
+}
+
+// stepTimeoutError is a command stopped by the step timeout. It is never
+// retried: the next attempt would get the same time to finish.
+type stepTimeoutError struct {
+	timeout time.Duration
+	err     error
+}
+
+func (e *stepTimeoutError) Error() string {
+	return fmt.Sprintf("timed out after %s: %v", e.timeout, e.err)
+}
+func (e *stepTimeoutError) Unwrap() error { return e.err }
+
+// stepError explains failures caused by the step timeout rather than by the
+// command itself.
+func stepError(ctx, stepCtx context.Context, err error) error {
+	if err != nil && ctx.Err() == nil && errors.Is(stepCtx.Err(), context.DeadlineExceeded) {
+		return &stepTimeoutError{timeout: stepTimeout(ctx), err: err}
+	}
+	return err
+}
+
+// query runs a read-only command and returns its output.
+func query(ctx context.Context, e executor.Executor, name string, args ...string) ([]byte, error) {
+	cmd := executor.New(name, args...)
+	cmd.ReadOnly = true
+	return executor.Output(ctx, e, cmd)
+}
@@ -0,0 +1,108 @@
+package installer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/lukeberry99/devtool/internal/config"
+)
+
+// resolveInstallOrder returns the tool names ordered so that every tool comes
+// after the tools listed in its Dependencies. Dependencies that are not part of
+// the given set are ignored here; callers report them separately.
+func resolveInstallOrder(tools map[string]config.ToolConfig) ([]string, error) {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	names := sortedToolNames(tools)
+
+	marks := make(map[string]int, len(tools))
+	order := make([]string, 0, len(tools))
+	var path []string
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch marks[name] {
+		case visited:
+			return nil
+		case visiting:
+			// Walk back along the current path to name the full cycle
+			start := 0
+			for i, n := range path {
+				if n == name {
+					start = i
+					break
+				}
+			}
+			cycle := append(append([]string{}, path[start:]...), name)
+			return fmt.Errorf("dependency cycle detected: %s", strings.Join(cycle, " -> "))
+		}
+
+		marks[name] = visiting
+		path = append(path, name)
+
+		deps := append([]string{}, tools[name].Dependencies...)
+		sort.Strings(deps)
+		for _, dep := range deps {
+			if _, ok := tools[dep]; !ok {
+				continue
+			}
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+
+		path = path[:len(path)-1]
+		marks[name] = visited
+		order = append(order, name)
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name); err != nil {
+			return nil, err
+		}
+	}
+
+	return order, nil
+}
+
+// warnUnmanagedDependencies reports dependencies that devtool will not install
+// in this run because they are disabled or missing from the configuration.
+func (r *ToolRunner) warnUnmanagedDependencies(enabled, all map[string]config.ToolConfig) {
+	for _, name := range sortedToolNames(enabled) {
+		for _, dep := range enabled[name].Dependencies {
+			if _, ok := enabled[dep]; ok {
+				continue
+			}
+			if _, ok := all[dep]; ok {
+				r.logger.Warn(fmt.Sprintf("%s depends on %s, which is disabled; assuming it is already present", name, dep))
+			} else {
+				r.logger.Warn(fmt.Sprintf("%s depends on %s, which is not in the configuration; assuming it is already present", name, dep))
+			}
+		}
+	}
+}
+
+func sortedToolNames(tools map[string]config.ToolConfig) []string {
+	names := make([]string, 0, len(tools))
+	for name := range tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// blockedBy returns the first dependency of a tool that did not install, if any.
+func blockedBy(toolConfig config.ToolConfig, unavailable map[string]string) (string, bool) {
+	for _, dep := range toolConfig.Dependencies {
+		if _, ok := unavailable[dep]; ok {
+			return dep, true
+		}
+	}
+	return "", false
+}
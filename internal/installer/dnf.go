@@ -0,0 +1,42 @@
+package installer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/lukeberry99/devtool/internal/executor"
+	"github.com/lukeberry99/devtool/internal/ui"
+)
+
+type DnfManager struct {
+	nativeManager
+}
+
+func NewDnfManager(logger *ui.Logger, exec executor.Executor) *DnfManager {
+	manager := &DnfManager{nativeManager: newNativeManager("dnf", "dnf", logger, exec)}
+	manager.refreshArgs = []string{"makecache"}
+	manager.installArgs = []string{"install", "-y"}
+	manager.removeArgs = []string{"remove", "-y"}
+	manager.cleanupArgs = []string{"clean", "packages"}
+	return manager
+}
+
+func (d *DnfManager) WithLogger(logger *ui.Logger) PackageManager {
+	clone := *d
+	clone.logger = logger
+	return &clone
+}
+
+func (d *DnfManager) IsPackageInstalled(ctx context.Context, pkg string) bool {
+	_, err := d.query(ctx, "rpm", "-q", pkg)
+	return err == nil
+}
+
+func (d *DnfManager) GetInstalledVersion(ctx context.Context, pkg string) (string, error) {
+	output, err := d.query(ctx, "rpm", "-q", "--queryformat", "%{VERSION}-%{RELEASE}", pkg)
+	if err != nil {
+		return "", fmt.Errorf("failed to get version for %s: %w", pkg, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
@@ -0,0 +1,311 @@
+package installer
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"text/template"
+
+	"github.com/lukeberry99/devtool/internal/config"
+	"github.com/lukeberry99/devtool/internal/executor"
+	"github.com/lukeberry99/devtool/internal/state"
+)
+
+const defaultBinDir = "~/.local/bin"
+
+// platformData is the data available to URL and binary templates.
+type platformData struct {
+	Version string
+	Tag     string
+	OS      string
+	Arch    string
+	GOOS    string
+	GOARCH  string
+}
+
+func newPlatformData(version string, archive config.ArchiveConfig) platformData {
+	data := platformData{
+		Version: version,
+		OS:      runtime.GOOS,
+		Arch:    runtime.GOARCH,
+		GOOS:    runtime.GOOS,
+		GOARCH:  runtime.GOARCH,
+	}
+	if mapped, ok := archive.OSMap[runtime.GOOS]; ok {
+		data.OS = mapped
+	}
+	if mapped, ok := archive.ArchMap[runtime.GOARCH]; ok {
+		data.Arch = mapped
+	}
+	return data
+}
+
+func renderTemplate(name, text string, data interface{}) (string, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s template: %w", name, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render %s template: %w", name, err)
+	}
+	return buf.String(), nil
+}
+
+func (r *ToolRunner) installFromDownload(ctx context.Context, name string, toolConfig config.ToolConfig) error {
+	download := toolConfig.DownloadConfig
+	if download == nil || download.URL == "" {
+		return fmt.Errorf("download configuration with a url is required for download installs")
+	}
+
+	data := newPlatformData(toolConfig.Version, download.ArchiveConfig)
+	artifactURL, err := renderTemplate("url", download.URL, data)
+	if err != nil {
+		return err
+	}
+
+	expected := download.Checksums[runtime.GOOS+"_"+runtime.GOARCH]
+	if expected == "" {
+		expected = download.SHA256
+	}
+	if locked, ok := r.lockedChecksum(name); ok {
+		expected = locked
+	}
+
+	r.logger.Progress(fmt.Sprintf("Installing %s from %s", name, artifactURL))
+
+	if err := r.declareDownload(ctx, artifactURL, expected, download.ArchiveConfig); err != nil {
+		return err
+	}
+	if r.dryRun {
+		return nil
+	}
+
+	files, sum, err := r.fetchAndInstall(ctx, name, toolConfig, artifactURL, expected, download.ArchiveConfig, data)
+	if err != nil {
+		return err
+	}
+
+	r.updateToolState(ctx, name, toolConfig, "download", func(status *state.ToolStatus) {
+		status.Files = files
+		status.BinaryPath = files[0]
+		status.URL = artifactURL
+		status.Checksum = sum
+	})
+
+	r.logger.Success(fmt.Sprintf("%s installed successfully", name))
+	return nil
+}
+
+// declareDownload describes the fetch of an artifact as a command, so dry runs
+// and plans list it and apply refuses downloads that were not planned.
+func (r *ToolRunner) declareDownload(ctx context.Context, artifactURL, expectedSHA string, archive config.ArchiveConfig) error {
+	sum := "unverified"
+	if expectedSHA != "" {
+		sum = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(expectedSHA), "sha256:"))
+	}
+	return executor.Declare(ctx, r.executor, executor.New("download", artifactURL, "sha256:"+sum, "--into", binDir(archive)))
+}
+
+// fetchAndInstall downloads artifactURL, verifies it against expectedSHA,
+// unpacks it and copies the selected binaries into the bin dir. It returns the
+// installed paths and the artifact's sha256.
+func (r *ToolRunner) fetchAndInstall(ctx context.Context, name string, toolConfig config.ToolConfig, artifactURL, expectedSHA string, archive config.ArchiveConfig, data platformData) ([]string, string, error) {
+	workDir, err := os.MkdirTemp("", "devtool-"+name+"-")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create download directory: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	// 1. Download while hashing
+	artifactName := artifactFileName(artifactURL)
+	artifact := filepath.Join(workDir, artifactName)
+	sum, err := r.downloadFile(ctx, artifactURL, artifact)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// 2. Verify checksum
+	if expectedSHA == "" {
+		if !archive.InsecureSkipChecksum {
+			return nil, "", fmt.Errorf("no sha256 known for %s (got %s); configure one or set insecure_skip_checksum: true", artifactName, sum)
+		}
+		r.logger.Warn(fmt.Sprintf("Installing %s without checksum verification (insecure_skip_checksum); got %s", name, sum))
+	} else if !checksumMatches(expectedSHA, sum) {
+		return nil, "", fmt.Errorf("checksum mismatch for %s: expected %s, got %s", artifactName, expectedSHA, sum)
+	} else {
+		r.logger.Debug(fmt.Sprintf("Verified sha256 of %s", artifactName))
+	}
+
+	// 3. Unpack
+	format := archive.Format
+	if format == "" {
+		format = detectArchiveFormat(artifactName)
+	}
+	extractDir := filepath.Join(workDir, "extract")
+	if err := extractArchive(ctx, r.executor, artifact, extractDir, format, archive.StripComponents, toolBinaryName(name, toolConfig)); err != nil {
+		return nil, "", fmt.Errorf("failed to unpack %s: %w", artifactName, err)
+	}
+
+	// 4. Install selected binaries
+	binaries, err := selectBinaries(name, toolConfig, archive, extractDir, data)
+	if err != nil {
+		return nil, "", err
+	}
+
+	dir := binDir(archive)
+	var installed []string
+	for _, binary := range binaries {
+		target := filepath.Join(dir, binary.name)
+		r.logger.Step(fmt.Sprintf("Installing %s to %s", binary.name, target))
+		if r.tx != nil {
+			if err := r.tx.backup(name, target); err != nil {
+				return nil, "", err
+			}
+		}
+		if err := copyExecutable(binary.source, target); err != nil {
+			return nil, "", fmt.Errorf("failed to install %s: %w", binary.name, err)
+		}
+		installed = append(installed, target)
+	}
+
+	if !dirOnPath(dir) {
+		r.logger.Warn(fmt.Sprintf("%s is not on your PATH", dir))
+	}
+
+	return installed, sum, nil
+}
+
+func (r *ToolRunner) downloadFile(ctx context.Context, artifactURL, dest string) (string, error) {
+	r.logger.Step(fmt.Sprintf("Downloading %s", artifactURL))
+
+	var sum string
+	err := r.retry.do(ctx, r.logger, "download "+artifactURL, func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, artifactURL, nil)
+		if err != nil {
+			return fmt.Errorf("invalid download url: %w", err)
+		}
+		req.Header.Set("User-Agent", "devtool")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("failed to download %s: %w", artifactURL, err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return &httpStatusError{url: artifactURL, status: resp.Status, code: resp.StatusCode}
+		}
+
+		file, err := os.Create(dest)
+		if err != nil {
+			return fmt.Errorf("failed to create download file: %w", err)
+		}
+		defer file.Close()
+
+		hasher := sha256.New()
+		written, err := io.Copy(io.MultiWriter(file, hasher), resp.Body)
+		if err != nil {
+			return fmt.Errorf("failed to download %s: %w", artifactURL, err)
+		}
+
+		r.logger.Debug(fmt.Sprintf("Downloaded %d bytes", written))
+		sum = hex.EncodeToString(hasher.Sum(nil))
+		return nil
+	})
+	return sum, err
+}
+
+type selectedBinary struct {
+	source string
+	name   string
+}
+
+// selectBinaries resolves the configured binaries against the extracted
+// archive. Without configuration it looks for a file named after the tool.
+func selectBinaries(name string, toolConfig config.ToolConfig, archive config.ArchiveConfig, extractDir string, data platformData) ([]selectedBinary, error) {
+	if len(archive.Binaries) == 0 {
+		binaryName := toolBinaryName(name, toolConfig)
+		var found string
+		filepath.Walk(extractDir, func(p string, info os.FileInfo, err error) error {
+			if err != nil || found != "" {
+				return err
+			}
+			if info.Mode().IsRegular() && info.Name() == binaryName {
+				found = p
+			}
+			return nil
+		})
+		if found == "" {
+			return nil, fmt.Errorf("binary %s not found in archive; set binaries in the configuration", binaryName)
+		}
+		return []selectedBinary{{source: found, name: binaryName}}, nil
+	}
+
+	var binaries []selectedBinary
+	for _, entry := range archive.Binaries {
+		rendered, err := renderTemplate("binaries", entry, data)
+		if err != nil {
+			return nil, err
+		}
+
+		source, target, _ := strings.Cut(rendered, ":")
+		if target == "" {
+			target = path.Base(source)
+		}
+
+		sourcePath := filepath.Join(extractDir, filepath.FromSlash(source))
+		if info, err := os.Stat(sourcePath); err != nil || info.IsDir() {
+			return nil, fmt.Errorf("binary %s not found in archive", source)
+		}
+		binaries = append(binaries, selectedBinary{source: sourcePath, name: target})
+	}
+
+	return binaries, nil
+}
+
+func toolBinaryName(name string, toolConfig config.ToolConfig) string {
+	if toolConfig.InstalledBinary != "" {
+		return toolConfig.InstalledBinary
+	}
+	return name
+}
+
+func binDir(archive config.ArchiveConfig) string {
+	if archive.BinDir != "" {
+		return expandPath(archive.BinDir)
+	}
+	return expandPath(defaultBinDir)
+}
+
+func artifactFileName(artifactURL string) string {
+	if parsed, err := url.Parse(artifactURL); err == nil && path.Base(parsed.Path) != "/" && path.Base(parsed.Path) != "." {
+		return path.Base(parsed.Path)
+	}
+	return "artifact"
+}
+
+func checksumMatches(expected, actual string) bool {
+	expected = strings.TrimPrefix(strings.TrimSpace(expected), "sha256:")
+	return strings.EqualFold(expected, actual)
+}
+
+func dirOnPath(dir string) bool {
+	for _, entry := range filepath.SplitList(os.Getenv("PATH")) {
+		if filepath.Clean(entry) == filepath.Clean(dir) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,368 @@
+package installer
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/lukeberry99/devtool/internal/config"
+	"github.com/lukeberry99/devtool/internal/executor"
+	"github.com/lukeberry99/devtool/internal/state"
+	"github.com/lukeberry99/devtool/internal/version"
+)
+
+// ecosystem installs packages through a language's own package manager.
+type ecosystem struct {
+	binary string
+	// lane serializes installs; global npm and cargo installs share state
+	lane sync.Mutex
+	// installArgs builds the install command for pkg; version is "" for
+	// latest, an exact version or a constraint rendered by constraint
+	installArgs func(pkg, version string) []string
+	// constraint renders a version constraint in the manager's own syntax;
+	// nil means the manager only installs exact versions
+	constraint func(c *version.Constraint) (string, error)
+	// uninstallArgs builds the removal command; nil means delete the binary
+	uninstallArgs func(pkg string) []string
+	// installedVersion reports the installed version of pkg, whose main
+	// executable is binaryName
+	installedVersion func(ctx context.Context, e executor.Executor, pkg, binaryName string) (string, error)
+}
+
+var ecosystems = map[string]*ecosystem{
+	"go": {
+		binary: "go",
+		installArgs: func(pkg, version string) []string {
+			if version == "" {
+				version = "latest"
+			} else if version[0] >= '0' && version[0] <= '9' {
+				// Module versions are tags; installed versions are recorded without the v
+				version = "v" + version
+			}
+			return []string{"install", pkg + "@" + version}
+		},
+		installedVersion: goInstalledVersion,
+	},
+	"cargo": {
+		binary: "cargo",
+		installArgs: func(pkg, version string) []string {
+			args := []string{"install", "--locked", pkg}
+			if version != "" {
+				args = append(args, "--version", version)
+			}
+			return args
+		},
+		constraint: func(c *version.Constraint) (string, error) {
+			return formatConstraint(c, ", ", "", map[string]string{"!=": ""})
+		},
+		uninstallArgs: func(pkg string) []string {
+			return []string{"uninstall", pkg}
+		},
+		installedVersion: cargoInstalledVersion,
+	},
+	"pipx": {
+		binary: "pipx",
+		installArgs: func(pkg, version string) []string {
+			switch {
+			case version == "":
+			case strings.ContainsAny(version[:1], "<>=!"):
+				pkg += version
+			default:
+				pkg += "==" + version
+			}
+			// --force so a version change replaces the existing venv
+			return []string{"install", "--force", pkg}
+		},
+		constraint: func(c *version.Constraint) (string, error) {
+			return formatConstraint(c, ",", "", map[string]string{"=": "=="})
+		},
+		uninstallArgs: func(pkg string) []string {
+			return []string{"uninstall", strings.SplitN(pkg, "[", 2)[0]}
+		},
+		installedVersion: pipxInstalledVersion,
+	},
+	"npm": {
+		binary: "npm",
+		installArgs: func(pkg, version string) []string {
+			if version == "" {
+				version = "latest"
+			}
+			return []string{"install", "-g", pkg + "@" + version}
+		},
+		constraint: func(c *version.Constraint) (string, error) {
+			return formatConstraint(c, " ", " || ", map[string]string{"!=": ""})
+		},
+		uninstallArgs: func(pkg string) []string {
+			return []string{"uninstall", "-g", pkg}
+		},
+		installedVersion: npmInstalledVersion,
+	},
+}
+
+func (r *ToolRunner) installFromEcosystem(ctx context.Context, name string, toolConfig config.ToolConfig) error {
+	eco := ecosystems[toolConfig.Source]
+	pkg := ecosystemPackage(name, toolConfig)
+	requested, err := eco.requestedVersion(toolConfig.Version)
+	if err != nil {
+		return err
+	}
+	args := eco.installArgs(pkg, requested)
+
+	r.logger.Progress(fmt.Sprintf("Installing %s with %s", name, eco.binary))
+
+	// Dry runs do not install the dependency providing the binary
+	if _, err := r.executor.LookPath(eco.binary); err != nil && !r.dryRun {
+		return fmt.Errorf("%s is required to install %s; add the tool providing it to dependencies", eco.binary, name)
+	}
+
+	// A package that was not there before is removed again on rollback
+	added := false
+	if r.tx != nil {
+		_, err := r.ecosystemVersion(ctx, name, toolConfig)
+		added = err != nil
+	}
+
+	eco.lane.Lock()
+	defer eco.lane.Unlock()
+
+	stdout, stderr := r.logger.Writer(), r.logger.ErrorWriter()
+	defer stdout.Close()
+	defer stderr.Close()
+
+	cmd := executor.New(eco.binary, args...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := runStep(ctx, r.executor, cmd); err != nil {
+		return fmt.Errorf("%s %s failed: %w", eco.binary, strings.Join(args, " "), err)
+	}
+	if added {
+		r.recordUndo(name, fmt.Sprintf("%s uninstall %s", eco.binary, pkg), func(ctx context.Context) error {
+			return r.uninstallFromEcosystem(ctx, name, toolConfig, state.ToolStatus{Source: toolConfig.Source})
+		})
+	}
+
+	// Update state tracking
+	r.updateToolState(ctx, name, toolConfig, toolConfig.Source, func(status *state.ToolStatus) {
+		if binaryPath, err := r.executor.LookPath(ecosystemBinary(name, toolConfig)); err == nil {
+			status.BinaryPath = binaryPath
+		}
+	})
+
+	r.logger.Success(fmt.Sprintf("%s installed successfully", name))
+	return nil
+}
+
+func (r *ToolRunner) ecosystemVersion(ctx context.Context, name string, toolConfig config.ToolConfig) (string, error) {
+	eco, ok := ecosystems[toolConfig.Source]
+	if !ok {
+		return "", fmt.Errorf("%s is not a language ecosystem source", toolConfig.Source)
+	}
+	return eco.installedVersion(ctx, r.executor, ecosystemPackage(name, toolConfig), ecosystemBinary(name, toolConfig))
+}
+
+func ecosystemPackage(name string, toolConfig config.ToolConfig) string {
+	if toolConfig.Package != "" {
+		return toolConfig.Package
+	}
+	return name
+}
+
+// ecosystemBinary is the executable a package provides. For Go modules that is
+// the last path element, ignoring a major version suffix such as /v2.
+func ecosystemBinary(name string, toolConfig config.ToolConfig) string {
+	if toolConfig.InstalledBinary != "" {
+		return toolConfig.InstalledBinary
+	}
+	if toolConfig.Source == "go" {
+		pkg := strings.TrimSuffix(ecosystemPackage(name, toolConfig), "/...")
+		base := path.Base(pkg)
+		if regexp.MustCompile(`^v[0-9]+$`).MatchString(base) {
+			base = path.Base(path.Dir(pkg))
+		}
+		return base
+	}
+	return name
+}
+
+// requestedVersion turns the configured version into what the manager is
+// asked to install: "" for the latest release on every channel, an exact
+// version as given, and a constraint in the manager's own syntax.
+func (e *ecosystem) requestedVersion(requirement string) (string, error) {
+	switch {
+	case version.IsChannel(requirement):
+		return "", nil
+	case !version.IsConstraint(requirement):
+		return requirement, nil
+	case e.constraint == nil:
+		return "", fmt.Errorf("%s installs need an exact version or a channel, not the constraint %q", e.binary, requirement)
+	}
+
+	constraint, err := version.ParseConstraint(requirement)
+	if err != nil {
+		return "", err
+	}
+	rendered, err := e.constraint(constraint)
+	if err != nil {
+		return "", fmt.Errorf("%s cannot install %q: %w", e.binary, requirement, err)
+	}
+	return rendered, nil
+}
+
+// formatConstraint writes the expanded comparators of c joined by and, and its
+// alternatives joined by or; an empty or means alternatives are unsupported.
+// ops renames operators, and renaming one to "" marks it unsupported.
+// Package managers skip prereleases unless asked for one, so the prerelease
+// bounds of the expanded form (>=1.2.0-0) are written as plain versions.
+func formatConstraint(c *version.Constraint, and, or string, ops map[string]string) (string, error) {
+	alternatives := c.Alternatives()
+	if len(alternatives) > 1 && or == "" {
+		return "", fmt.Errorf("alternatives (||) are not supported")
+	}
+
+	var formatted []string
+	for _, comparators := range alternatives {
+		if len(comparators) == 0 {
+			return "", nil // "*" matches anything
+		}
+		var terms []string
+		for _, cmp := range comparators {
+			op := cmp.Op
+			if renamed, ok := ops[op]; ok {
+				if renamed == "" {
+					return "", fmt.Errorf("%s is not supported", op)
+				}
+				op = renamed
+			}
+			v := cmp.Version
+			v.Parts = 3
+			if v.Pre == "0" {
+				v.Pre = ""
+			}
+			terms = append(terms, op+v.String())
+		}
+		formatted = append(formatted, strings.Join(terms, and))
+	}
+	return strings.Join(formatted, or), nil
+}
+
+// goInstalledVersion reads the module version embedded in the installed binary.
+func goInstalledVersion(ctx context.Context, e executor.Executor, pkg, binaryName string) (string, error) {
+	binaryPath, err := goBinaryPath(ctx, e, binaryName)
+	if err != nil {
+		return "", err
+	}
+
+	output, err := query(ctx, e, "go", "version", "-m", binaryPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read build info from %s: %w", binaryPath, err)
+	}
+
+	// Lines look like "\tmod\tgolang.org/x/tools/gopls\tv0.16.1\th1:..."
+	scanner := bufio.NewScanner(strings.NewReader(string(output)))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 3 && fields[0] == "mod" {
+			return strings.TrimPrefix(fields[2], "v"), nil
+		}
+	}
+	return "", fmt.Errorf("no module version in build info of %s", binaryPath)
+}
+
+func goBinaryPath(ctx context.Context, e executor.Executor, binaryName string) (string, error) {
+	output, err := query(ctx, e, "go", "env", "GOBIN", "GOPATH")
+	if err != nil {
+		return "", fmt.Errorf("failed to query go env: %w", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	binDir := ""
+	if len(lines) > 0 {
+		binDir = strings.TrimSpace(lines[0])
+	}
+	if binDir == "" && len(lines) > 1 {
+		binDir = filepath.Join(filepath.SplitList(strings.TrimSpace(lines[1]))[0], "bin")
+	}
+
+	binaryPath := filepath.Join(binDir, binaryName)
+	if _, err := os.Stat(binaryPath); err != nil {
+		return "", fmt.Errorf("%s not found in %s", binaryName, binDir)
+	}
+	return binaryPath, nil
+}
+
+// cargoInstalledVersion parses "cargo install --list", whose package lines look
+// like "ripgrep v14.1.0:".
+func cargoInstalledVersion(ctx context.Context, e executor.Executor, pkg, _ string) (string, error) {
+	output, err := query(ctx, e, "cargo", "install", "--list")
+	if err != nil {
+		return "", fmt.Errorf("failed to list cargo installs: %w", err)
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(string(output)))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, " ") {
+			continue
+		}
+		fields := strings.Fields(strings.TrimSuffix(line, ":"))
+		if len(fields) >= 2 && fields[0] == pkg {
+			return strings.TrimPrefix(fields[1], "v"), nil
+		}
+	}
+	return "", fmt.Errorf("%s is not installed with cargo", pkg)
+}
+
+func pipxInstalledVersion(ctx context.Context, e executor.Executor, pkg, _ string) (string, error) {
+	output, err := query(ctx, e, "pipx", "list", "--json")
+	if err != nil {
+		return "", fmt.Errorf("failed to list pipx installs: %w", err)
+	}
+
+	var listing struct {
+		Venvs map[string]struct {
+			Metadata struct {
+				MainPackage struct {
+					PackageVersion string `json:"package_version"`
+				} `json:"main_package"`
+			} `json:"metadata"`
+		} `json:"venvs"`
+	}
+	if err := json.Unmarshal(output, &listing); err != nil {
+		return "", fmt.Errorf("failed to parse pipx output: %w", err)
+	}
+
+	// Venvs are keyed by the bare package name, without extras or specifiers
+	venv, ok := listing.Venvs[strings.SplitN(pkg, "[", 2)[0]]
+	if !ok || venv.Metadata.MainPackage.PackageVersion == "" {
+		return "", fmt.Errorf("%s is not installed with pipx", pkg)
+	}
+	return venv.Metadata.MainPackage.PackageVersion, nil
+}
+
+func npmInstalledVersion(ctx context.Context, e executor.Executor, pkg, _ string) (string, error) {
+	// npm ls exits non-zero for unrelated problems in the global tree, so
+	// parse whatever it printed
+	output, _ := query(ctx, e, "npm", "ls", "-g", "--depth=0", "--json", pkg)
+
+	var listing struct {
+		Dependencies map[string]struct {
+			Version string `json:"version"`
+		} `json:"dependencies"`
+	}
+	if err := json.Unmarshal(output, &listing); err != nil {
+		return "", fmt.Errorf("failed to parse npm output: %w", err)
+	}
+
+	dep, ok := listing.Dependencies[pkg]
+	if !ok || dep.Version == "" {
+		return "", fmt.Errorf("%s is not installed with npm", pkg)
+	}
+	return dep.Version, nil
+}
@@ -1,66 +1,116 @@
 package installer
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	"runtime"
 	"strings"
+	"sync"
 
+	"github.com/lukeberry99/devtool/internal/config"
+	"github.com/lukeberry99/devtool/internal/executor"
 	"github.com/lukeberry99/devtool/internal/ui"
 )
 
 type HomebrewManager struct {
-	logger *ui.Logger
-	dryRun bool
+	logger   *ui.Logger
+	executor executor.Executor
+	// lane serializes package operations; brew holds its own global lock and
+	// concurrent invocations fail rather than wait.
+	lane  *sync.Mutex
+	retry retryPolicy
+	// taps holds the configured tap URLs and tapped the taps known to be
+	// present, shared by clones and guarded by lane
+	taps      []config.TapConfig
+	tapped    *tapSet
+	inventory *brewInventory
 }
 
-func NewHomebrewManager(logger *ui.Logger, dryRun bool) *HomebrewManager {
+// tapSet caches the output of brew tap, loaded on first use.
+type tapSet struct {
+	names map[string]bool
+}
+
+func NewHomebrewManager(logger *ui.Logger, exec executor.Executor) *HomebrewManager {
 	return &HomebrewManager{
-		logger: logger,
-		dryRun: dryRun,
+		logger:    logger,
+		executor:  exec,
+		lane:      &sync.Mutex{},
+		retry:     newRetryPolicy(config.RetryConfig{}, nil),
+		tapped:    &tapSet{},
+		inventory: &brewInventory{},
 	}
 }
 
-func (h *HomebrewManager) EnsureInstalled() error {
+// withTaps returns a manager that taps the given taps from their configured
+// URLs.
+func (h *HomebrewManager) withTaps(taps []config.TapConfig) *HomebrewManager {
+	clone := *h
+	clone.taps = taps
+	return &clone
+}
+
+// WithLogger returns a manager that logs through logger but shares the same
+// serialized lane.
+func (h *HomebrewManager) WithLogger(logger *ui.Logger) PackageManager {
+	return h.withLogger(logger)
+}
+
+func (h *HomebrewManager) withLogger(logger *ui.Logger) *HomebrewManager {
+	clone := *h
+	clone.logger = logger
+	return &clone
+}
+
+// withRetry returns a manager that retries network-bound brew commands under
+// policy.
+func (h *HomebrewManager) withRetry(policy retryPolicy) *HomebrewManager {
+	clone := *h
+	clone.retry = policy
+	return &clone
+}
+
+func (h *HomebrewManager) Name() string {
+	return "homebrew"
+}
+
+func (h *HomebrewManager) EnsureInstalled(ctx context.Context) error {
 	// Replicate exact Homebrew installation logic from bash
 	if h.isInstalled() {
 		h.logger.Step("Homebrew is already installed")
-		return h.update()
+		return h.update(ctx)
 	}
 
 	h.logger.Progress("Installing Homebrew...")
-	if h.dryRun {
-		h.logger.Step("[DRY RUN] Would install Homebrew")
-		return nil
-	}
 
 	// Execute Homebrew installation script
-	return h.install()
+	return h.install(ctx)
 }
 
 func (h *HomebrewManager) isInstalled() bool {
-	_, err := exec.LookPath("brew")
+	_, err := h.executor.LookPath("brew")
 	return err == nil
 }
 
-func (h *HomebrewManager) install() error {
-	// Check if we're on macOS (required for Homebrew)
-	if runtime.GOOS != "darwin" {
-		return fmt.Errorf("homebrew installation is only supported on macOS")
+func (h *HomebrewManager) install(ctx context.Context) error {
+	// Homebrew supports macOS and Linux
+	if runtime.GOOS != "darwin" && runtime.GOOS != "linux" {
+		return fmt.Errorf("homebrew installation is only supported on macOS and Linux")
 	}
 
 	h.logger.Info("Downloading and installing Homebrew...")
 
 	// Use the official Homebrew installation script
-	cmd := exec.Command("/bin/bash", "-c",
+	cmd := executor.New("/bin/bash", "-c",
 		`/bin/bash -c "$(curl -fsSL https://raw.githubusercontent.com/Homebrew/install/HEAD/install.sh)"`)
-
+	cmd.Interactive = true
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
-	cmd.Stdin = os.Stdin
 
-	if err := cmd.Run(); err != nil {
+	if err := h.executor.Run(ctx, cmd); err != nil {
 		return fmt.Errorf("failed to install Homebrew: %w", err)
 	}
 
@@ -68,16 +118,15 @@ func (h *HomebrewManager) install() error {
 	return nil
 }
 
-func (h *HomebrewManager) update() error {
-	if h.dryRun {
-		h.logger.Info("[DRY RUN] Would update Homebrew")
-		return nil
-	}
-
+func (h *HomebrewManager) update(ctx context.Context) error {
 	h.logger.Debug("Updating Homebrew...")
-	cmd := exec.Command("brew", "update")
-
-	if err := cmd.Run(); err != nil {
+	err := h.retry.run(ctx, h.logger, h.executor, "brew update", func() executor.Command {
+		return h.command("update")
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 		h.logger.Warn("Failed to update Homebrew, continuing anyway")
 		return nil // Don't fail on update errors
 	}
@@ -85,136 +134,327 @@ func (h *HomebrewManager) update() error {
 	return nil
 }
 
-func (h *HomebrewManager) InstallCask(caskName string) error {
-	if h.isCaskInstalled(caskName) {
+// InstallCask installs a cask, passing args such as --no-quarantine to brew.
+func (h *HomebrewManager) InstallCask(ctx context.Context, caskName string, args ...string) error {
+	h.lane.Lock()
+	defer h.lane.Unlock()
+
+	if h.isCaskInstalled(ctx, caskName) {
 		h.logger.Debug(fmt.Sprintf("Cask %s already installed", caskName))
 		return nil
 	}
-	return h.installPackageWithArgs(caskName, []string{"--cask"})
+	return h.installPackageWithArgs(ctx, caskName, append([]string{"--cask"}, args...))
 }
 
-func (h *HomebrewManager) InstallPackages(packages []string) error {
-	h.logger.Progress(fmt.Sprintf("Installing packages: %v", packages))
+// Tap adds a tap unless it is already present and reports whether it did.
+// The URL comes from the configured taps; without one brew clones the
+// GitHub repository <user>/homebrew-<repo>.
+func (h *HomebrewManager) Tap(ctx context.Context, name string) (bool, error) {
+	h.lane.Lock()
+	defer h.lane.Unlock()
+
+	if h.tapped.names == nil {
+		output, err := h.query(ctx, "tap")
+		if err != nil {
+			return false, fmt.Errorf("brew tap failed: %w", err)
+		}
+		h.tapped.names = make(map[string]bool)
+		for _, tap := range strings.Fields(string(output)) {
+			h.tapped.names[strings.ToLower(tap)] = true
+		}
+	}
+	if h.tapped.names[strings.ToLower(name)] {
+		return false, nil
+	}
 
-	if h.dryRun {
-		h.logger.Step(fmt.Sprintf("[DRY RUN] Would install packages: %v", packages))
+	args := []string{"tap", name}
+	if url := (config.HomebrewConfig{Taps: h.taps}).TapURL(name); url != "" {
+		args = append(args, url)
+	}
+	h.logger.Step(fmt.Sprintf("Tapping %s...", name))
+	if err := h.runNetwork(ctx, args...); err != nil {
+		return false, fmt.Errorf("brew %s failed: %w", strings.Join(args, " "), err)
+	}
+	h.tapped.names[strings.ToLower(name)] = true
+	return true, nil
+}
+
+// Untap removes a tap. Brew refuses while formulae from it are installed.
+func (h *HomebrewManager) Untap(ctx context.Context, name string) error {
+	h.lane.Lock()
+	defer h.lane.Unlock()
+
+	cmd := h.command("untap", name)
+	stdout, stderr := h.logger.Writer(), h.logger.ErrorWriter()
+	defer stdout.Close()
+	defer stderr.Close()
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	if err := h.executor.Run(ctx, cmd); err != nil {
+		return fmt.Errorf("brew untap %s failed: %w", name, err)
+	}
+	delete(h.tapped.names, strings.ToLower(name))
+	return nil
+}
+
+// InstallPackages installs the formulae that are missing with a single brew
+// call. When that fails, the ones still missing are installed one at a time
+// so the error names the packages at fault.
+func (h *HomebrewManager) InstallPackages(ctx context.Context, packages []string) error {
+	h.lane.Lock()
+	defer h.lane.Unlock()
+
+	missing := h.missing(ctx, packages)
+	if len(missing) == 0 {
+		h.logger.Debug(fmt.Sprintf("Packages %v already installed", packages))
 		return nil
 	}
 
-	for _, pkg := range packages {
+	h.logger.Progress(fmt.Sprintf("Installing packages: %v", missing))
+	if len(missing) > 1 {
+		err := h.installBatch(ctx, missing)
+		if err == nil || ctx.Err() != nil {
+			return err
+		}
+		h.logger.Warn(fmt.Sprintf("Installing %d packages at once failed, retrying one at a time: %v", len(missing), err))
+		missing = h.missing(ctx, missing)
+	}
+
+	var errs []error
+	for _, pkg := range missing {
 		h.logger.Step(fmt.Sprintf("Installing %s...", pkg))
-		if err := h.installPackage(pkg); err != nil {
-			return fmt.Errorf("failed to install %s: %w", pkg, err)
+		if err := h.installPackage(ctx, pkg); err != nil {
+			errs = append(errs, fmt.Errorf("failed to install %s: %w", pkg, err))
 		}
 	}
+	return errors.Join(errs...)
+}
 
+// InstallBatch installs formulae with a single brew call, without falling back
+// to single installs; callers that retry failures themselves use it.
+func (h *HomebrewManager) InstallBatch(ctx context.Context, packages []string) error {
+	h.lane.Lock()
+	defer h.lane.Unlock()
+
+	return h.installBatch(ctx, h.missing(ctx, packages))
+}
+
+func (h *HomebrewManager) installBatch(ctx context.Context, packages []string) error {
+	if len(packages) == 0 {
+		return nil
+	}
+	defer h.inventory.invalidate()
+
+	args := append([]string{"install"}, packages...)
+	if err := h.runNetwork(ctx, args...); err != nil {
+		return fmt.Errorf("brew install %s failed: %w", strings.Join(packages, " "), err)
+	}
 	return nil
 }
 
-func (h *HomebrewManager) InstallPackageWithArgs(pkg string, args []string) error {
-	if h.isPackageInstalled(pkg) {
+// missing returns the packages brew does not report as installed.
+func (h *HomebrewManager) missing(ctx context.Context, packages []string) []string {
+	var missing []string
+	for _, pkg := range packages {
+		if !h.IsPackageInstalled(ctx, pkg) {
+			missing = append(missing, pkg)
+		}
+	}
+	return missing
+}
+
+func (h *HomebrewManager) InstallPackageWithArgs(ctx context.Context, pkg string, args []string) error {
+	h.lane.Lock()
+	defer h.lane.Unlock()
+
+	if h.IsPackageInstalled(ctx, pkg) {
 		h.logger.Debug(fmt.Sprintf("Package %s already installed", pkg))
 		return nil
 	}
 
 	h.logger.Info(fmt.Sprintf("Installing %s with args %v...", pkg, args))
-	if err := h.installPackageWithArgs(pkg, args); err != nil {
+	if err := h.installPackageWithArgs(ctx, pkg, args); err != nil {
 		return fmt.Errorf("failed to install %s: %w", pkg, err)
 	}
 	return nil
 }
 
-func (h *HomebrewManager) isCaskInstalled(caskName string) bool {
-	cmd := exec.Command("brew", "list", "--cask", caskName)
-	return cmd.Run() == nil
+// UninstallPackages removes formulae.
+func (h *HomebrewManager) UninstallPackages(ctx context.Context, packages []string) error {
+	return h.uninstall(ctx, packages, nil)
+}
+
+func (h *HomebrewManager) UninstallCask(ctx context.Context, caskName string) error {
+	return h.uninstall(ctx, []string{caskName}, []string{"--cask"})
 }
 
-func (h *HomebrewManager) isPackageInstalled(pkg string) bool {
-	if h.dryRun {
-		return false // In dry-run mode, assume nothing is installed
+func (h *HomebrewManager) uninstall(ctx context.Context, packages, args []string) error {
+	h.lane.Lock()
+	defer h.lane.Unlock()
+	defer h.inventory.invalidate()
+
+	cmdArgs := append(append([]string{"uninstall"}, args...), packages...)
+	cmd := h.command(cmdArgs...)
+	stdout, stderr := h.logger.Writer(), h.logger.ErrorWriter()
+	defer stdout.Close()
+	defer stderr.Close()
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	if err := h.executor.Run(ctx, cmd); err != nil {
+		return fmt.Errorf("brew %s failed: %w", strings.Join(cmdArgs, " "), err)
+	}
+	return nil
+}
+
+// BrewOutdated is an entry from brew outdated --json=v2.
+type BrewOutdated struct {
+	Name             string
+	InstalledVersion string
+	CurrentVersion   string
+	Cask             bool
+}
+
+// Outdated returns outdated formulae and casks keyed by name.
+func (h *HomebrewManager) Outdated(ctx context.Context) (map[string]BrewOutdated, error) {
+	output, err := h.query(ctx, "outdated", "--json=v2")
+	if err != nil {
+		return nil, fmt.Errorf("brew outdated failed: %w", err)
+	}
+
+	type entry struct {
+		Name              string          `json:"name"`
+		InstalledVersions json.RawMessage `json:"installed_versions"`
+		CurrentVersion    string          `json:"current_version"`
+	}
+	var report struct {
+		Formulae []entry `json:"formulae"`
+		Casks    []entry `json:"casks"`
+	}
+	if err := json.Unmarshal(output, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse brew outdated output: %w", err)
 	}
 
-	// Check if package is installed by trying to find it in brew list
-	cmd := exec.Command("brew", "list", "--formula", pkg)
-	if err := cmd.Run(); err == nil {
-		return true
+	// installed_versions is a list for formulae and may be a plain string for casks
+	installed := func(raw json.RawMessage) string {
+		var versions []string
+		if err := json.Unmarshal(raw, &versions); err == nil && len(versions) > 0 {
+			return versions[len(versions)-1]
+		}
+		var version string
+		json.Unmarshal(raw, &version)
+		return version
 	}
 
-	// Also check casks
-	cmd = exec.Command("brew", "list", "--cask", pkg)
-	return cmd.Run() == nil
+	outdated := make(map[string]BrewOutdated)
+	for _, f := range report.Formulae {
+		outdated[f.Name] = BrewOutdated{Name: f.Name, InstalledVersion: installed(f.InstalledVersions), CurrentVersion: f.CurrentVersion}
+	}
+	for _, c := range report.Casks {
+		outdated[c.Name] = BrewOutdated{Name: c.Name, InstalledVersion: installed(c.InstalledVersions), CurrentVersion: c.CurrentVersion, Cask: true}
+	}
+	return outdated, nil
 }
 
-func (h *HomebrewManager) installPackage(pkg string) error {
-	if h.dryRun {
-		h.logger.Info(fmt.Sprintf("[DRY RUN] Would install package: %s", pkg))
-		return nil
+// Upgrade upgrades a single formula, or a cask when cask is set.
+func (h *HomebrewManager) Upgrade(ctx context.Context, pkg string, cask bool) error {
+	h.lane.Lock()
+	defer h.lane.Unlock()
+
+	defer h.inventory.invalidate()
+
+	args := []string{"upgrade"}
+	if cask {
+		args = append(args, "--cask")
 	}
+	args = append(args, pkg)
 
-	cmd := exec.Command("brew", "install", pkg)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	if err := h.runNetwork(ctx, args...); err != nil {
+		return fmt.Errorf("brew %s failed: %w", strings.Join(args, " "), err)
+	}
+	return nil
+}
 
-	if err := cmd.Run(); err != nil {
+// runNetwork runs a brew command that downloads, retrying transient failures.
+func (h *HomebrewManager) runNetwork(ctx context.Context, args ...string) error {
+	stdout, stderr := h.logger.Writer(), h.logger.ErrorWriter()
+	defer stdout.Close()
+	defer stderr.Close()
+
+	return h.retry.run(ctx, h.logger, h.executor, "brew "+strings.Join(args, " "), func() executor.Command {
+		cmd := h.command(args...)
+		cmd.Stdout = stdout
+		cmd.Stderr = stderr
+		return cmd
+	})
+}
+
+// command builds a brew command. Cask installers may ask for a sudo password,
+// so cask commands keep the terminal.
+func (h *HomebrewManager) command(args ...string) executor.Command {
+	cmd := executor.New("brew", args...)
+	cmd.Interactive = contains(args, "--cask")
+	return cmd
+}
+
+// query runs a brew command that only reads, and returns its output.
+func (h *HomebrewManager) query(ctx context.Context, args ...string) ([]byte, error) {
+	return query(ctx, h.executor, "brew", args...)
+}
+
+func (h *HomebrewManager) isCaskInstalled(ctx context.Context, caskName string) bool {
+	pkg, ok, _ := h.lookup(ctx, caskName)
+	return ok && pkg.Cask
+}
+
+// IsPackageInstalled reports whether a formula or cask is installed.
+func (h *HomebrewManager) IsPackageInstalled(ctx context.Context, pkg string) bool {
+	_, ok, err := h.lookup(ctx, pkg)
+	if err != nil {
+		h.logger.Debug(fmt.Sprintf("Could not check whether %s is installed: %v", pkg, err))
+	}
+	return ok
+}
+
+func (h *HomebrewManager) installPackage(ctx context.Context, pkg string) error {
+	defer h.inventory.invalidate()
+
+	if err := h.runNetwork(ctx, "install", pkg); err != nil {
 		return fmt.Errorf("brew install %s failed: %w", pkg, err)
 	}
 
 	return nil
 }
 
-func (h *HomebrewManager) installPackageWithArgs(pkg string, args []string) error {
-	if h.dryRun {
-		h.logger.Info(fmt.Sprintf("[DRY RUN] Would install package: %s with args %v", pkg, args))
-		return nil
-	}
+func (h *HomebrewManager) installPackageWithArgs(ctx context.Context, pkg string, args []string) error {
+	defer h.inventory.invalidate()
 
 	// Build command: brew install [args...] [package]
 	cmdArgs := append([]string{"install"}, args...)
 	cmdArgs = append(cmdArgs, pkg)
 
-	cmd := exec.Command("brew", cmdArgs...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	if err := cmd.Run(); err != nil {
+	if err := h.runNetwork(ctx, cmdArgs...); err != nil {
 		return fmt.Errorf("brew install %s %s failed: %w", strings.Join(args, " "), pkg, err)
 	}
 
 	return nil
 }
 
-func (h *HomebrewManager) GetInstalledVersion(pkg string) (string, error) {
-	if h.dryRun {
-		return "dry-run-version", nil
-	}
-
-	// Use brew list --versions to get installed version
-	cmd := exec.Command("brew", "list", "--versions", pkg)
-	output, err := cmd.Output()
+func (h *HomebrewManager) GetInstalledVersion(ctx context.Context, pkg string) (string, error) {
+	installed, ok, err := h.lookup(ctx, pkg)
 	if err != nil {
 		return "", fmt.Errorf("failed to get version for %s: %w", pkg, err)
 	}
-
-	// Parse output like "k9s 0.50.9" to extract version
-	outputStr := strings.TrimSpace(string(output))
-	parts := strings.Fields(outputStr)
-	if len(parts) >= 2 {
-		return parts[1], nil // Return the version part
+	if !ok {
+		return "", fmt.Errorf("%s is not installed", pkg)
 	}
-
-	return "", fmt.Errorf("unexpected brew list output format: %s", outputStr)
+	return installed.Version, nil
 }
 
-func (h *HomebrewManager) Cleanup() error {
-	if h.dryRun {
-		h.logger.Info("[DRY RUN] Would cleanup Homebrew")
-		return nil
-	}
-
-	h.logger.Debug("Cleaning up Homebrew...")
-	cmd := exec.Command("brew", "cleanup")
-
-	if err := cmd.Run(); err != nil {
+func (h *HomebrewManager) Cleanup(ctx context.Context) error {
+	h.logger.Step("Cleaning up Homebrew...")
+	if err := h.executor.Run(ctx, h.command("cleanup")); err != nil {
 		h.logger.Warn("Failed to cleanup Homebrew, continuing anyway")
 		return nil // Don't fail on cleanup errors
 	}
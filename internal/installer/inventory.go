@@ -0,0 +1,107 @@
+package installer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// brewInventory caches what brew reports as installed. It is loaded with a
+// single brew info call and reloaded on the next lookup after devtool changes
+// anything.
+type brewInventory struct {
+	mu       sync.Mutex
+	packages map[string]brewPackage // by lower-case name, full name, alias and old name; nil until loaded
+}
+
+type brewPackage struct {
+	Name    string
+	Version string
+	Cask    bool
+}
+
+func (i *brewInventory) invalidate() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.packages = nil
+}
+
+// lookup finds an installed formula or cask by any name brew accepts for it.
+func (h *HomebrewManager) lookup(ctx context.Context, pkg string) (brewPackage, bool, error) {
+	h.inventory.mu.Lock()
+	defer h.inventory.mu.Unlock()
+
+	if h.inventory.packages == nil {
+		packages, err := h.loadInventory(ctx)
+		if err != nil {
+			return brewPackage{}, false, err
+		}
+		h.inventory.packages = packages
+	}
+
+	found, ok := h.inventory.packages[strings.ToLower(pkg)]
+	return found, ok, nil
+}
+
+func (h *HomebrewManager) loadInventory(ctx context.Context) (map[string]brewPackage, error) {
+	output, err := h.query(ctx, "info", "--json=v2", "--installed")
+	if err != nil {
+		return nil, fmt.Errorf("brew info failed: %w", err)
+	}
+
+	var report struct {
+		Formulae []struct {
+			Name      string   `json:"name"`
+			FullName  string   `json:"full_name"`
+			Aliases   []string `json:"aliases"`
+			Oldnames  []string `json:"oldnames"`
+			LinkedKeg string   `json:"linked_keg"`
+			Installed []struct {
+				Version string `json:"version"`
+			} `json:"installed"`
+		} `json:"formulae"`
+		Casks []struct {
+			Token     string   `json:"token"`
+			FullToken string   `json:"full_token"`
+			OldTokens []string `json:"old_tokens"`
+			Installed *string  `json:"installed"`
+		} `json:"casks"`
+	}
+	if err := json.Unmarshal(output, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse brew info output: %w", err)
+	}
+
+	packages := make(map[string]brewPackage)
+	add := func(pkg brewPackage, names ...string) {
+		for _, name := range names {
+			if name != "" {
+				packages[strings.ToLower(name)] = pkg
+			}
+		}
+	}
+
+	for _, f := range report.Formulae {
+		if len(f.Installed) == 0 {
+			continue
+		}
+		// The linked keg is the version in use when several are installed
+		version := f.LinkedKeg
+		if version == "" {
+			version = f.Installed[len(f.Installed)-1].Version
+		}
+		pkg := brewPackage{Name: f.Name, Version: version}
+		add(pkg, append(append([]string{f.Name, f.FullName}, f.Aliases...), f.Oldnames...)...)
+	}
+	for _, c := range report.Casks {
+		if c.Installed == nil {
+			continue
+		}
+		pkg := brewPackage{Name: c.Token, Version: *c.Installed, Cask: true}
+		add(pkg, append([]string{c.Token, c.FullToken}, c.OldTokens...)...)
+	}
+
+	h.logger.Debug(fmt.Sprintf("Loaded %d installed Homebrew packages", len(report.Formulae)+len(report.Casks)))
+	return packages, nil
+}
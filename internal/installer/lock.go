@@ -0,0 +1,180 @@
+package installer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/lukeberry99/devtool/internal/config"
+	"github.com/lukeberry99/devtool/internal/lockfile"
+	"github.com/lukeberry99/devtool/internal/state"
+	"github.com/lukeberry99/devtool/internal/version"
+)
+
+// PinTools returns the tools with their versions replaced by the ones the
+// lockfile recorded: the exact version, or the commit for source builds. It
+// fails when an enabled tool is missing from the lockfile or changed source.
+func PinTools(tools map[string]config.ToolConfig, lock *lockfile.Lockfile) (map[string]config.ToolConfig, error) {
+	pinned := make(map[string]config.ToolConfig, len(tools))
+	var problems []string
+
+	for _, name := range sortedToolNames(tools) {
+		toolConfig := tools[name]
+		if !toolConfig.Enabled {
+			pinned[name] = toolConfig
+			continue
+		}
+
+		entry, ok := lock.Tools[name]
+		switch {
+		case !ok:
+			problems = append(problems, fmt.Sprintf("%s is not in the lockfile", name))
+			continue
+		case entry.Source != toolConfig.Source:
+			problems = append(problems, fmt.Sprintf("%s is locked as %s but configured as %s", name, entry.Source, toolConfig.Source))
+			continue
+		}
+
+		switch {
+		case toolConfig.Source == "build" && entry.Commit != "":
+			toolConfig.Version = entry.Commit
+		case entry.Version != "":
+			toolConfig.Version = entry.Version
+		}
+		pinned[name] = toolConfig
+	}
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("configuration does not match the lockfile (run install without --locked to update it):\n  %s", strings.Join(problems, "\n  "))
+	}
+	return pinned, nil
+}
+
+// lockedChecksum is the artifact checksum a locked install must match.
+func (r *ToolRunner) lockedChecksum(name string) (string, bool) {
+	if r.lock == nil {
+		return "", false
+	}
+	entry, ok := r.lock.Tools[name]
+	return entry.Checksum, ok && entry.Checksum != ""
+}
+
+// verifyLocked checks that what was installed is exactly what the lockfile
+// recorded. Package managers cannot always install an older version, so a
+// mismatch is an error rather than silently accepted drift.
+func (r *ToolRunner) verifyLocked(name string) error {
+	if r.lock == nil || r.dryRun || r.stateManager == nil {
+		return nil
+	}
+
+	entry := r.lock.Tools[name]
+	status, _ := r.stateManager.GetToolStatus(name)
+
+	switch {
+	case entry.Commit != "":
+		if status.Commit != entry.Commit {
+			return fmt.Errorf("%s is at commit %s, lockfile has %s", name, shortSHA(status.Commit), shortSHA(entry.Commit))
+		}
+	case entry.Checksum != "":
+		if !checksumMatches(entry.Checksum, status.Checksum) {
+			return fmt.Errorf("%s artifact has sha256 %s, lockfile has %s", name, status.Checksum, entry.Checksum)
+		}
+	case entry.Version != "":
+		if version.Normalize(status.Version) != version.Normalize(entry.Version) {
+			return fmt.Errorf("%s is at version %s, lockfile has %s", name, status.Version, entry.Version)
+		}
+	}
+	return nil
+}
+
+// WriteLockfile records the installed state of the given tools in the
+// lockfile at path, keeping entries for other configured tools and dropping
+// tools that are no longer configured.
+func (r *ToolRunner) WriteLockfile(path string, installed, all map[string]config.ToolConfig) error {
+	lock, err := lockfile.Load(path)
+	if err != nil {
+		lock = lockfile.New()
+	}
+
+	for name := range lock.Tools {
+		if _, ok := all[name]; !ok {
+			delete(lock.Tools, name)
+		}
+	}
+
+	for _, name := range sortedToolNames(installed) {
+		toolConfig := installed[name]
+		status, ok := r.stateManager.GetToolStatus(name)
+		if !toolConfig.Enabled || !ok || !status.Installed {
+			continue
+		}
+		lock.Tools[name] = lockEntry(name, toolConfig, status)
+	}
+
+	if err := lock.Save(path); err != nil {
+		return err
+	}
+	r.logger.Debug(fmt.Sprintf("Wrote lockfile %s", path))
+	return nil
+}
+
+// unlockTool drops an uninstalled tool from the lockfile next to the
+// configuration, if there is one.
+func (r *ToolRunner) unlockTool(name string) error {
+	if r.configDir == "" {
+		return nil
+	}
+	path := lockfile.Path(r.configDir)
+	lock, err := lockfile.Load(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if _, ok := lock.Tools[name]; !ok {
+		return nil
+	}
+	delete(lock.Tools, name)
+	return lock.Save(path)
+}
+
+func lockEntry(name string, toolConfig config.ToolConfig, status state.ToolStatus) lockfile.Entry {
+	entry := lockfile.Entry{
+		Source:  toolConfig.Source,
+		Version: version.Normalize(status.Version),
+	}
+
+	switch toolConfig.Source {
+	case "homebrew":
+		entry.Formula = homebrewName(name, toolConfig)
+		entry.Tap = homebrewTap(entry.Formula, toolConfig)
+	case "build":
+		entry.Commit = status.Commit
+	case "release":
+		// Tags are kept verbatim so they resolve to the same release
+		entry.Version = status.Version
+		entry.URL = status.URL
+		entry.Checksum = status.Checksum
+	case "download":
+		// The configured version is what the URL template was rendered with
+		entry.Version = toolConfig.Version
+		entry.URL = status.URL
+		entry.Checksum = status.Checksum
+	}
+
+	return entry
+}
+
+// homebrewTap is the tap a formula or cask comes from: the prefix of a fully
+// qualified name, or one of the default taps.
+func homebrewTap(name string, toolConfig config.ToolConfig) string {
+	if parts := strings.Split(name, "/"); len(parts) == 3 {
+		return parts[0] + "/" + parts[1]
+	}
+	if toolConfig.Cask || contains(toolConfig.HomebrewArgs, "--cask") {
+		return "homebrew/cask"
+	}
+	return "homebrew/core"
+}
@@ -0,0 +1,238 @@
+package installer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/lukeberry99/devtool/internal/config"
+	"github.com/lukeberry99/devtool/internal/executor"
+	"github.com/lukeberry99/devtool/internal/state"
+	"github.com/lukeberry99/devtool/internal/version"
+)
+
+// OutdatedTool is a managed tool with a newer version available.
+type OutdatedTool struct {
+	Name    string
+	Source  string
+	Current string
+	Latest  string
+	cask    bool
+}
+
+// OutdatedTools checks every installed, configured tool for a newer version.
+// Homebrew tools are answered by a single brew outdated call, source builds by
+// comparing the checked-out commit with the remote ref, and releases by
+// resolving the latest release. Other sources are reported when the installed
+// version no longer satisfies the configured one.
+func (r *ToolRunner) OutdatedTools(ctx context.Context, tools map[string]config.ToolConfig) ([]OutdatedTool, error) {
+	if r.stateManager == nil {
+		return nil, fmt.Errorf("state is required to check for outdated tools")
+	}
+
+	installed := r.stateManager.GetAllTools()
+
+	var brewOutdated map[string]BrewOutdated
+	for name, status := range installed {
+		if _, configured := tools[name]; configured && status.Installed && status.Source == "homebrew" {
+			var err error
+			if brewOutdated, err = r.homebrew.Outdated(ctx); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+
+	var outdated []OutdatedTool
+	for _, name := range sortedToolNames(tools) {
+		toolConfig := tools[name]
+		status, ok := installed[name]
+		if !ok || !status.Installed {
+			continue
+		}
+
+		switch status.Source {
+		case "homebrew":
+			formula := homebrewName(name, toolConfig)
+			entry, ok := brewOutdated[formula]
+			if !ok {
+				// Tapped formulae are reported by their short name
+				entry, ok = brewOutdated[path.Base(formula)]
+			}
+			if !ok {
+				continue
+			}
+			// brew upgrade can only move to the newest version, which must
+			// still satisfy a pinned tool's constraint
+			if satisfied, err := version.Satisfies(entry.CurrentVersion, toolConfig.Version); err == nil && !satisfied {
+				r.logger.Debug(fmt.Sprintf("Skipping %s: %s %s is outside %s", name, formula, entry.CurrentVersion, toolConfig.Version))
+				continue
+			}
+			outdated = append(outdated, OutdatedTool{
+				Name:    name,
+				Source:  status.Source,
+				Current: entry.InstalledVersion,
+				Latest:  entry.CurrentVersion,
+				cask:    entry.Cask,
+			})
+		case "built_from_source":
+			if toolConfig.BuildConfig == nil {
+				continue
+			}
+			local, remote, err := r.compareBuildRefs(ctx, name, toolConfig)
+			if err != nil {
+				r.logger.Warn(fmt.Sprintf("Could not check %s for updates: %v", name, err))
+				continue
+			}
+			// The checkout stays on the newer commit after a rollback
+			if status.RolledBack && status.Commit != "" {
+				local = status.Commit
+			}
+			if local != remote {
+				outdated = append(outdated, OutdatedTool{
+					Name:    name,
+					Source:  status.Source,
+					Current: shortSHA(local),
+					Latest:  shortSHA(remote),
+				})
+			}
+		case "release":
+			if toolConfig.ReleaseConfig == nil {
+				continue
+			}
+			if !isLatestRelease(toolConfig.Version) {
+				if current, err := recordedCurrent(status, toolConfig); err == nil && !current {
+					outdated = append(outdated, OutdatedTool{
+						Name:    name,
+						Source:  status.Source,
+						Current: status.Version,
+						Latest:  toolConfig.Version,
+					})
+				}
+				continue
+			}
+			rel, err := r.resolveRelease(ctx, toolConfig.ReleaseConfig, toolConfig.Version)
+			if err != nil {
+				r.logger.Warn(fmt.Sprintf("Could not check %s for updates: %v", name, err))
+				continue
+			}
+			if rel.TagName != status.Version {
+				outdated = append(outdated, OutdatedTool{
+					Name:    name,
+					Source:  status.Source,
+					Current: status.Version,
+					Latest:  rel.TagName,
+				})
+			}
+		default:
+			// Without an upstream check, report installs that no longer
+			// satisfy the configured version
+			if version.IsChannel(toolConfig.Version) {
+				r.logger.Debug(fmt.Sprintf("Skipping %s: update checks are not supported for %s tools", name, status.Source))
+				continue
+			}
+			if current, err := recordedCurrent(status, toolConfig); err == nil && !current {
+				outdated = append(outdated, OutdatedTool{
+					Name:    name,
+					Source:  status.Source,
+					Current: version.Normalize(status.Version),
+					Latest:  toolConfig.Version,
+				})
+			}
+		}
+	}
+
+	return outdated, nil
+}
+
+// compareBuildRefs returns the commit checked out in the build directory and
+// the commit the configured ref points to upstream.
+func (r *ToolRunner) compareBuildRefs(ctx context.Context, name string, toolConfig config.ToolConfig) (string, string, error) {
+	r = r.withRetry(toolConfig.Retry)
+	local, err := newBuildWorkspace(name, r.logger, r.executor, r.retry).head(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	target := buildTarget(toolConfig.Version)
+	// Annotated tags are listed twice; the peeled ^{} entry is the commit
+	var output bytes.Buffer
+	err = r.retry.run(ctx, r.logger, r.executor, "git ls-remote "+toolConfig.BuildConfig.Repository, func() executor.Command {
+		output.Reset()
+		cmd := executor.New("git", "ls-remote", toolConfig.BuildConfig.Repository, target, target+"^{}")
+		cmd.Stdout = &output
+		cmd.ReadOnly = true
+		return cmd
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("git ls-remote failed: %w", err)
+	}
+
+	var remote string
+	for _, line := range strings.Split(strings.TrimSpace(output.String()), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		if remote == "" || strings.HasSuffix(fields[1], "^{}") {
+			remote = fields[0]
+		}
+	}
+	if remote == "" {
+		return "", "", fmt.Errorf("ref %s not found in %s", target, toolConfig.BuildConfig.Repository)
+	}
+
+	return local, remote, nil
+}
+
+// UpgradeTools upgrades the given outdated tools and refreshes their state.
+// Homebrew tools go through brew upgrade; everything else is reinstalled.
+func (r *ToolRunner) UpgradeTools(ctx context.Context, outdated []OutdatedTool, tools map[string]config.ToolConfig) error {
+	sort.Slice(outdated, func(i, j int) bool { return outdated[i].Name < outdated[j].Name })
+
+	failed := 0
+	for i, tool := range outdated {
+		if ctx.Err() != nil {
+			return fmt.Errorf("upgrade interrupted with %d of %d tools left: %w", len(outdated)-i, len(outdated), ctx.Err())
+		}
+		r.logger.Section(fmt.Sprintf("Upgrading %s (%s → %s)", tool.Name, tool.Current, tool.Latest))
+
+		var err error
+		if tool.Source == "homebrew" {
+			toolConfig := tools[tool.Name]
+			if err = r.withRetry(toolConfig.Retry).homebrew.Upgrade(ctx, homebrewName(tool.Name, toolConfig), tool.cask); err == nil {
+				r.updateToolState(ctx, tool.Name, toolConfig, "homebrew", func(status *state.ToolStatus) {
+					status.Tap = toolConfig.Tap
+					status.Cask = tool.cask
+				})
+				err = r.syncService(ctx, tool.Name, toolConfig, true)
+			}
+		} else {
+			forced := *r
+			forced.force = true
+			err = forced.InstallTool(ctx, tool.Name, tools[tool.Name])
+		}
+
+		if err != nil {
+			r.logger.Error(fmt.Sprintf("Failed to upgrade %s: %v", tool.Name, err))
+			failed++
+			continue
+		}
+		r.logger.Success(fmt.Sprintf("%s upgraded", tool.Name))
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d tools failed to upgrade", failed, len(outdated))
+	}
+	return nil
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 12 {
+		return sha[:12]
+	}
+	return sha
+}
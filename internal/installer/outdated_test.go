@@ -0,0 +1,52 @@
+package installer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lukeberry99/devtool/internal/config"
+	"github.com/lukeberry99/devtool/internal/executor"
+	"github.com/lukeberry99/devtool/internal/state"
+)
+
+func TestOutdatedHomebrew(t *testing.T) {
+	const report = `{"formulae":[
+		{"name":"jq","installed_versions":["1.6"],"current_version":"1.7.1"},
+		{"name":"ripgrep","installed_versions":["14.0.0"],"current_version":"14.1.1"},
+		{"name":"k9s","installed_versions":["0.32.0"],"current_version":"0.32.5"}
+	],"casks":[
+		{"name":"aerospace","installed_versions":"0.14.0","current_version":"0.15.0"}
+	]}`
+	fake := executor.NewFake().
+		Path("brew", "/opt/homebrew/bin/brew").
+		On("brew outdated --json=v2", executor.Response{Stdout: report})
+	runner, stateManager := newTestRunner(t, fake, Options{})
+
+	tools := map[string]config.ToolConfig{
+		"jq":        {Source: "homebrew", Version: "~1.6", Enabled: true},
+		"ripgrep":   {Source: "homebrew", Version: "^14", Enabled: true},
+		"k9s":       {Source: "homebrew", Enabled: true},
+		"aerospace": {Source: "homebrew", Cask: true, Tap: "nikitabobko/tap", Enabled: true},
+	}
+	for name := range tools {
+		stateManager.UpdateToolStatus(name, state.ToolStatus{Installed: true, Source: "homebrew"})
+	}
+
+	outdated, err := runner.OutdatedTools(context.Background(), tools)
+	if err != nil {
+		t.Fatalf("OutdatedTools: %v", err)
+	}
+
+	want := map[string]string{"aerospace": "0.15.0", "k9s": "0.32.5", "ripgrep": "14.1.1"}
+	if len(outdated) != len(want) {
+		t.Errorf("outdated = %+v, want %v", outdated, want)
+	}
+	for _, tool := range outdated {
+		if latest, ok := want[tool.Name]; !ok || tool.Latest != latest {
+			t.Errorf("%s reported with %s, want %v", tool.Name, tool.Latest, want)
+		}
+		if tool.Name == "aerospace" && !tool.cask {
+			t.Error("aerospace is not upgraded as a cask")
+		}
+	}
+}
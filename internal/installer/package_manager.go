@@ -0,0 +1,243 @@
+package installer
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/lukeberry99/devtool/internal/config"
+	"github.com/lukeberry99/devtool/internal/executor"
+	"github.com/lukeberry99/devtool/internal/ui"
+)
+
+// PackageManager is a system package manager that tools can be installed
+// through. Implementations serialize their own package operations.
+type PackageManager interface {
+	// Name identifies the backend, e.g. "homebrew" or "apt". It is also the key
+	// used to look up per-manager package names in ToolConfig.Packages.
+	Name() string
+	EnsureInstalled(ctx context.Context) error
+	InstallPackages(ctx context.Context, packages []string) error
+	UninstallPackages(ctx context.Context, packages []string) error
+	IsPackageInstalled(ctx context.Context, pkg string) bool
+	GetInstalledVersion(ctx context.Context, pkg string) (string, error)
+	Cleanup(ctx context.Context) error
+	WithLogger(logger *ui.Logger) PackageManager
+}
+
+// DetectSystemPackageManager returns the native package manager for this
+// machine: Homebrew on macOS, and apt, dnf or pacman on Linux.
+func DetectSystemPackageManager(logger *ui.Logger, exec executor.Executor, homebrew *HomebrewManager) (PackageManager, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		return homebrew, nil
+	case "linux":
+		distro := readOSRelease()
+		ids := append([]string{distro["ID"]}, strings.Fields(distro["ID_LIKE"])...)
+		for _, id := range ids {
+			switch id {
+			case "debian", "ubuntu":
+				return NewAptManager(logger, exec), nil
+			case "fedora", "rhel", "centos":
+				return NewDnfManager(logger, exec), nil
+			case "arch":
+				return NewPacmanManager(logger, exec), nil
+			}
+		}
+
+		// Unknown distribution, fall back to whatever is available
+		if _, err := exec.LookPath("apt-get"); err == nil {
+			return NewAptManager(logger, exec), nil
+		}
+		if _, err := exec.LookPath("dnf"); err == nil {
+			return NewDnfManager(logger, exec), nil
+		}
+		if _, err := exec.LookPath("pacman"); err == nil {
+			return NewPacmanManager(logger, exec), nil
+		}
+		return nil, fmt.Errorf("no supported package manager found (apt, dnf or pacman)")
+	default:
+		return nil, fmt.Errorf("no supported package manager for %s", runtime.GOOS)
+	}
+}
+
+// readOSRelease parses /etc/os-release into a key/value map.
+func readOSRelease() map[string]string {
+	values := make(map[string]string)
+
+	file, err := os.Open("/etc/os-release")
+	if err != nil {
+		return values
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok {
+			continue
+		}
+		values[key] = strings.Trim(value, `"'`)
+	}
+	return values
+}
+
+// systemPackages resolves the package names for a tool on the given manager.
+// ToolConfig.Packages is keyed by manager name (or distro ID) and may list
+// several space-separated packages; the tool name is the fallback.
+func systemPackages(name string, toolConfig config.ToolConfig, manager PackageManager) []string {
+	keys := []string{manager.Name()}
+	if runtime.GOOS == "linux" {
+		keys = append(keys, readOSRelease()["ID"])
+	}
+
+	for _, key := range keys {
+		if packages, ok := toolConfig.Packages[key]; ok && key != "" {
+			return strings.Fields(packages)
+		}
+	}
+	return []string{name}
+}
+
+// nativeManager holds what the Linux package managers have in common. Each
+// backend supplies the commands for its own tooling.
+type nativeManager struct {
+	name     string
+	binary   string
+	logger   *ui.Logger
+	executor executor.Executor
+	lane     *sync.Mutex
+	// installed is set once this run installed a package. Like lane, it is
+	// shared with the copies WithLogger makes.
+	installed *bool
+
+	refreshArgs []string
+	installArgs []string
+	removeArgs  []string
+	cleanupArgs []string
+	env         []string
+}
+
+func newNativeManager(name, binary string, logger *ui.Logger, exec executor.Executor) nativeManager {
+	return nativeManager{
+		name:      name,
+		binary:    binary,
+		logger:    logger,
+		executor:  exec,
+		lane:      &sync.Mutex{},
+		installed: new(bool),
+	}
+}
+
+func (n *nativeManager) Name() string {
+	return n.name
+}
+
+func (n *nativeManager) EnsureInstalled(ctx context.Context) error {
+	if _, err := n.executor.LookPath(n.binary); err != nil {
+		return fmt.Errorf("%s is not available on this system", n.binary)
+	}
+	n.logger.Step(fmt.Sprintf("Using %s for system packages", n.name))
+
+	if len(n.refreshArgs) == 0 {
+		return nil
+	}
+
+	n.lane.Lock()
+	defer n.lane.Unlock()
+
+	n.logger.Debug(fmt.Sprintf("Refreshing %s package index...", n.name))
+	if err := n.executor.Run(ctx, n.privileged(n.refreshArgs...)); err != nil {
+		n.logger.Warn(fmt.Sprintf("Failed to refresh %s package index, continuing anyway", n.name))
+	}
+	return nil
+}
+
+func (n *nativeManager) InstallPackages(ctx context.Context, packages []string) error {
+	n.lane.Lock()
+	defer n.lane.Unlock()
+
+	n.logger.Progress(fmt.Sprintf("Installing packages with %s: %v", n.name, packages))
+
+	cmd := n.privileged(append(append([]string{}, n.installArgs...), packages...)...)
+	stdout, stderr := n.logger.Writer(), n.logger.ErrorWriter()
+	defer stdout.Close()
+	defer stderr.Close()
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	if err := n.executor.Run(ctx, cmd); err != nil {
+		return fmt.Errorf("%s install %s failed: %w", n.name, strings.Join(packages, " "), err)
+	}
+	*n.installed = true
+	return nil
+}
+
+func (n *nativeManager) UninstallPackages(ctx context.Context, packages []string) error {
+	n.lane.Lock()
+	defer n.lane.Unlock()
+
+	n.logger.Progress(fmt.Sprintf("Removing packages with %s: %v", n.name, packages))
+
+	cmd := n.privileged(append(append([]string{}, n.removeArgs...), packages...)...)
+	stdout, stderr := n.logger.Writer(), n.logger.ErrorWriter()
+	defer stdout.Close()
+	defer stderr.Close()
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	if err := n.executor.Run(ctx, cmd); err != nil {
+		return fmt.Errorf("%s remove %s failed: %w", n.name, strings.Join(packages, " "), err)
+	}
+	return nil
+}
+
+// Cleanup clears the package cache, but only after this run installed
+// something, so a run that changed nothing doesn't ask for sudo.
+func (n *nativeManager) Cleanup(ctx context.Context) error {
+	n.lane.Lock()
+	defer n.lane.Unlock()
+
+	if !*n.installed {
+		n.logger.Debug(fmt.Sprintf("Nothing was installed with %s, skipping cleanup", n.name))
+		return nil
+	}
+
+	n.logger.Step(fmt.Sprintf("Cleaning up %s...", n.name))
+	if err := n.executor.Run(ctx, n.privileged(n.cleanupArgs...)); err != nil {
+		n.logger.Warn(fmt.Sprintf("Failed to cleanup %s, continuing anyway", n.name))
+	}
+	return nil
+}
+
+// query runs a read-only command of the manager's own tooling and returns its
+// output.
+func (n *nativeManager) query(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return query(ctx, n.executor, name, args...)
+}
+
+// privileged runs the manager binary as root, through sudo when needed. Sudo
+// only accepts VAR=value arguments when its policy allows setting the
+// environment, so the variables go through env instead. Sudo may prompt for a
+// password, so the command keeps the terminal.
+func (n *nativeManager) privileged(args ...string) executor.Command {
+	if os.Geteuid() == 0 {
+		cmd := executor.New(n.binary, args...)
+		cmd.Env = n.env
+		return cmd
+	}
+
+	var sudoArgs []string
+	if len(n.env) > 0 {
+		sudoArgs = append(append([]string{"env"}, n.env...), n.binary)
+	} else {
+		sudoArgs = []string{n.binary}
+	}
+	cmd := executor.New("sudo", append(sudoArgs, args...)...)
+	cmd.Interactive = true
+	return cmd
+}
@@ -0,0 +1,48 @@
+package installer
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/lukeberry99/devtool/internal/executor"
+	"github.com/lukeberry99/devtool/internal/ui"
+)
+
+func TestPrivilegedPassesEnvThroughEnv(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("commands run without sudo as root")
+	}
+	manager := NewAptManager(ui.NewLogger(false), executor.NewFake())
+
+	want := "sudo env DEBIAN_FRONTEND=noninteractive apt-get install -y jq"
+	if got := manager.privileged("install", "-y", "jq").String(); got != want {
+		t.Errorf("privileged() = %s, want %s", got, want)
+	}
+}
+
+func TestNativeCleanupOnlyAfterInstall(t *testing.T) {
+	fake := executor.NewFake()
+	manager := NewAptManager(ui.NewLogger(false), fake)
+	install := manager.privileged("install", "-y", "jq").String()
+	cleanup := manager.privileged("autoclean").String()
+	fake.On(install).On(cleanup)
+
+	if err := manager.Cleanup(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if calls := fake.Calls(); len(calls) != 0 {
+		t.Errorf("cleanup without installs ran %q", calls)
+	}
+
+	// Tools install through per-tool copies of the manager
+	if err := manager.WithLogger(ui.NewLogger(false)).InstallPackages(context.Background(), []string{"jq"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.Cleanup(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if calls := fake.Calls(); len(calls) != 2 || calls[1] != cleanup {
+		t.Errorf("commands = %q, want %s after the install", calls, cleanup)
+	}
+}
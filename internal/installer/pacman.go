@@ -0,0 +1,48 @@
+package installer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/lukeberry99/devtool/internal/executor"
+	"github.com/lukeberry99/devtool/internal/ui"
+)
+
+type PacmanManager struct {
+	nativeManager
+}
+
+func NewPacmanManager(logger *ui.Logger, exec executor.Executor) *PacmanManager {
+	// No separate refresh: a bare "pacman -Sy" risks a partial upgrade
+	manager := &PacmanManager{nativeManager: newNativeManager("pacman", "pacman", logger, exec)}
+	manager.installArgs = []string{"-S", "--needed", "--noconfirm"}
+	manager.removeArgs = []string{"-R", "--noconfirm"}
+	manager.cleanupArgs = []string{"-Sc", "--noconfirm"}
+	return manager
+}
+
+func (p *PacmanManager) WithLogger(logger *ui.Logger) PackageManager {
+	clone := *p
+	clone.logger = logger
+	return &clone
+}
+
+func (p *PacmanManager) IsPackageInstalled(ctx context.Context, pkg string) bool {
+	_, err := p.query(ctx, "pacman", "-Q", pkg)
+	return err == nil
+}
+
+func (p *PacmanManager) GetInstalledVersion(ctx context.Context, pkg string) (string, error) {
+	output, err := p.query(ctx, "pacman", "-Q", pkg)
+	if err != nil {
+		return "", fmt.Errorf("failed to get version for %s: %w", pkg, err)
+	}
+
+	// Parse output like "ripgrep 14.1.0-1"
+	parts := strings.Fields(string(output))
+	if len(parts) >= 2 {
+		return parts[1], nil
+	}
+	return "", fmt.Errorf("unexpected pacman output format: %s", strings.TrimSpace(string(output)))
+}
@@ -0,0 +1,299 @@
+package installer
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/lukeberry99/devtool/internal/config"
+	"github.com/lukeberry99/devtool/internal/executor"
+	"github.com/lukeberry99/devtool/internal/version"
+)
+
+const planFormat = 1
+
+// Reasons a plan installs a tool.
+const (
+	ReasonMissing         = "missing"
+	ReasonVersionMismatch = "version_mismatch"
+	ReasonForced          = "forced"
+	// ReasonUpstream tools follow a channel and compare against upstream
+	// while installing; they may turn out to be current.
+	ReasonUpstream = "tracks_upstream"
+	// ReasonService tools are current but their service is not.
+	ReasonService = "service"
+)
+
+// Plan is the reviewed outcome of an install: what would change and the exact
+// commands that would run. Applying it executes only those commands, and only
+// while state and configuration are what they were when it was made.
+type Plan struct {
+	Format    int       `json:"format"`
+	CreatedAt time.Time `json:"created_at"`
+	Host      string    `json:"host,omitempty"`
+	Force     bool      `json:"force,omitempty"`
+	// Tools is the selection the plan was computed for.
+	Tools             []string     `json:"tools"`
+	StateFingerprint  string       `json:"state_fingerprint"`
+	ConfigFingerprint string       `json:"config_fingerprint"`
+	Setup             []string     `json:"setup,omitempty"`
+	Actions           []PlanAction `json:"actions"`
+	Cleanup           []string     `json:"cleanup,omitempty"`
+}
+
+// PlanAction is one tool the plan installs.
+type PlanAction struct {
+	Tool      string   `json:"tool"`
+	Source    string   `json:"source"`
+	Reason    string   `json:"reason"`
+	Installed string   `json:"installed,omitempty"`
+	Wanted    string   `json:"wanted,omitempty"`
+	Commands  []string `json:"commands"`
+}
+
+// Commands lists every command of the plan in the order it runs.
+func (p *Plan) Commands() []string {
+	var commands []string
+	for _, step := range p.Steps() {
+		commands = append(commands, step...)
+	}
+	return commands
+}
+
+// Steps groups the commands of the plan as executor.NewVerifier expects them:
+// the setup, the commands of each action and the cleanup.
+func (p *Plan) Steps() [][]string {
+	steps := [][]string{p.Setup}
+	for _, action := range p.Actions {
+		steps = append(steps, action.Commands)
+	}
+	return append(steps, p.Cleanup)
+}
+
+// Save writes the plan as indented JSON.
+func (p *Plan) Save(path string) error {
+	// Keep commands readable for review: no \u003e for >
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(p); err != nil {
+		return fmt.Errorf("failed to encode plan: %w", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write plan: %w", err)
+	}
+	return nil
+}
+
+// LoadPlan reads a plan written by Save.
+func LoadPlan(path string) (*Plan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan: %w", err)
+	}
+
+	var plan Plan
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, fmt.Errorf("failed to parse plan %s: %w", path, err)
+	}
+	if plan.Format != planFormat {
+		return nil, fmt.Errorf("plan %s has format %d; this devtool understands %d", path, plan.Format, planFormat)
+	}
+	return &plan, nil
+}
+
+// planner attributes the commands recorded during a dry run to the tool being
+// installed.
+type planner struct {
+	recorder *executor.Recorder
+
+	mu      sync.Mutex
+	actions []PlanAction
+	bounds  [][2]int // recorded command range of each action
+	failed  []string
+}
+
+func (p *planner) record(action PlanAction, install func() error) error {
+	start := len(p.recorder.Commands())
+	err := install()
+	end := len(p.recorder.Commands())
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err != nil {
+		p.failed = append(p.failed, fmt.Sprintf("%s: %v", action.Tool, err))
+	}
+	// A service that is already as configured is not a change
+	if err == nil && action.Reason == ReasonService && end == start {
+		return nil
+	}
+	p.actions = append(p.actions, action)
+	p.bounds = append(p.bounds, [2]int{start, end})
+	return err
+}
+
+// Plan works out what InstallTools would do for tools without changing
+// anything. It needs a dry-run runner; tools are planned one at a time so each
+// command can be attributed to its tool.
+func (r *ToolRunner) Plan(ctx context.Context, tools map[string]config.ToolConfig) (*Plan, error) {
+	recorder, ok := r.executor.(*executor.Recorder)
+	if !ok {
+		return nil, fmt.Errorf("plans can only be computed in dry-run mode")
+	}
+
+	planning := *r
+	planning.jobs = 1
+	planning.planner = &planner{recorder: recorder}
+	if err := planning.InstallTools(ctx, tools); err != nil {
+		return nil, err
+	}
+	if failed := planning.planner.failed; len(failed) > 0 {
+		return nil, fmt.Errorf("could not plan %s", strings.Join(failed, "; "))
+	}
+
+	selected := enabledTools(tools)
+	hostname, _ := os.Hostname()
+	plan := &Plan{
+		Format:            planFormat,
+		CreatedAt:         time.Now().UTC().Truncate(time.Second),
+		Host:              hostname,
+		Force:             r.force,
+		Tools:             sortedToolNames(selected),
+		StateFingerprint:  r.stateManager.Fingerprint(),
+		ConfigFingerprint: configFingerprint(selected),
+		Actions:           []PlanAction{},
+	}
+
+	commands := recorder.Commands()
+	lines := func(from, to int) []string {
+		out := []string{}
+		for _, cmd := range commands[from:to] {
+			out = append(out, cmd.String())
+		}
+		return out
+	}
+
+	// Commands before the first tool set up the package managers, and those
+	// after the last one clean them up. Anything run between two tools is
+	// part of moving on to the next one.
+	next := 0
+	for i, action := range planning.planner.actions {
+		bounds := planning.planner.bounds[i]
+		if i == 0 {
+			plan.Setup = lines(0, bounds[0])
+			next = bounds[0]
+		}
+		action.Commands = lines(next, bounds[1])
+		plan.Actions = append(plan.Actions, action)
+		next = bounds[1]
+	}
+	if len(plan.Actions) == 0 {
+		plan.Setup = lines(0, len(commands))
+		next = len(commands)
+	}
+	plan.Cleanup = lines(next, len(commands))
+
+	return plan, nil
+}
+
+// planAction describes why a tool that is not current will be installed.
+func (r *ToolRunner) planAction(name string, toolConfig config.ToolConfig) PlanAction {
+	action := PlanAction{
+		Tool:   name,
+		Source: toolConfig.Source,
+		Wanted: toolConfig.Version,
+	}
+
+	status, exists := r.stateManager.GetToolStatus(name)
+	if exists && status.Installed {
+		action.Installed = status.Version
+	}
+
+	switch {
+	case r.force:
+		action.Reason = ReasonForced
+	case !exists || !status.Installed:
+		action.Reason = ReasonMissing
+	case version.IsChannel(toolConfig.Version) && toolConfig.Source != "release":
+		action.Reason = ReasonUpstream
+	default:
+		action.Reason = ReasonVersionMismatch
+	}
+	return action
+}
+
+// Apply installs the tools of a plan, refusing when state or configuration
+// changed since it was made. The runner must run commands through an
+// executor.Verifier built from the plan's commands.
+func (r *ToolRunner) Apply(ctx context.Context, plan *Plan, tools map[string]config.ToolConfig) error {
+	verifier, ok := r.executor.(*executor.Verifier)
+	if !ok {
+		return fmt.Errorf("plans must be applied through a verifying executor")
+	}
+	if r.force != plan.Force {
+		return fmt.Errorf("plan was made with force=%t but is applied with force=%t", plan.Force, r.force)
+	}
+
+	if fingerprint := r.stateManager.Fingerprint(); fingerprint != plan.StateFingerprint {
+		return fmt.Errorf("installed tools changed since the plan was made; run devtool plan again")
+	}
+
+	selected := make(map[string]config.ToolConfig, len(plan.Tools))
+	for _, name := range plan.Tools {
+		toolConfig, ok := tools[name]
+		if !ok || !toolConfig.Enabled {
+			return fmt.Errorf("%s is planned but no longer enabled in the configuration; run devtool plan again", name)
+		}
+		selected[name] = toolConfig
+	}
+	if configFingerprint(selected) != plan.ConfigFingerprint {
+		return fmt.Errorf("configuration changed since the plan was made; run devtool plan again")
+	}
+
+	if len(plan.Actions) == 0 {
+		r.logger.Success("Plan has nothing to do")
+		return nil
+	}
+
+	if err := r.InstallTools(ctx, selected); err != nil {
+		return err
+	}
+
+	if unrun := verifier.Unrun(); len(unrun) > 0 {
+		r.logger.Info(fmt.Sprintf("%d planned commands were not needed:", len(unrun)))
+		for _, line := range unrun {
+			r.logger.Step(line)
+		}
+	}
+	return nil
+}
+
+func enabledTools(tools map[string]config.ToolConfig) map[string]config.ToolConfig {
+	enabled := make(map[string]config.ToolConfig)
+	for name, toolConfig := range tools {
+		if toolConfig.Enabled {
+			enabled[name] = toolConfig
+		}
+	}
+	return enabled
+}
+
+// configFingerprint identifies the configuration of a set of tools.
+func configFingerprint(tools map[string]config.ToolConfig) string {
+	h := sha256.New()
+	for _, name := range sortedToolNames(tools) {
+		data, _ := yaml.Marshal(tools[name])
+		fmt.Fprintf(h, "%s\n%s\n", name, data)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
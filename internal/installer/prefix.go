@@ -0,0 +1,237 @@
+package installer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lukeberry99/devtool/internal/config"
+	"github.com/lukeberry99/devtool/internal/state"
+	"github.com/lukeberry99/devtool/internal/version"
+)
+
+// defaultRetain is how many source build prefixes are kept per tool.
+const defaultRetain = 3
+
+// Source builds install into ~/.devtool/opt/<tool>/<version-or-sha>. The
+// "current" symlink next to them selects the active build, and the entries of
+// its bin/ directory are linked into the bin dir through that symlink, so
+// switching builds is a single atomic rename.
+
+func optDir(name string) string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".devtool", "opt", name)
+}
+
+func currentLink(name string) string {
+	return filepath.Join(optDir(name), "current")
+}
+
+func buildBinDir(buildConfig *config.BuildConfig) string {
+	if buildConfig.BinDir != "" {
+		return expandPath(buildConfig.BinDir)
+	}
+	return expandPath(defaultBinDir)
+}
+
+// newPrefix picks the prefix for a build: the tag for pinned versions and the
+// commit otherwise. Rebuilding the same commit gets a numbered sibling so the
+// active build stays intact until the switch.
+func newPrefix(name, configured, commit string) string {
+	id := shortSHA(commit)
+	if target := buildTarget(configured); target != "master" && target != "stable" && !version.IsCommit(target) {
+		id = strings.ReplaceAll(target, "/", "-")
+	}
+
+	prefix := filepath.Join(optDir(name), id)
+	for i := 2; ; i++ {
+		if _, err := os.Lstat(prefix); os.IsNotExist(err) {
+			return prefix
+		}
+		prefix = filepath.Join(optDir(name), fmt.Sprintf("%s.%d", id, i))
+	}
+}
+
+// prefixPopulated reports whether the install steps put anything in prefix.
+// Steps that ignore $PREFIX leave it empty.
+func prefixPopulated(prefix string) bool {
+	entries, err := os.ReadDir(prefix)
+	return err == nil && len(entries) > 0
+}
+
+// prefixIntact reports whether a recorded prefix is still on disk. Builds
+// installed without a prefix have nothing to check.
+func prefixIntact(prefix string) bool {
+	if prefix == "" {
+		return true
+	}
+	_, err := os.Stat(prefix)
+	return err == nil
+}
+
+// activatePrefix points the tool's current symlink at prefix and links its
+// binaries into binDir. Links from the previous build that the new one does
+// not provide are removed. It returns the links now in place.
+func (r *ToolRunner) activatePrefix(name, prefix, binDir string, previousLinks []string) ([]string, error) {
+	if err := switchSymlink(prefix, currentLink(name)); err != nil {
+		return nil, fmt.Errorf("failed to activate %s: %w", prefix, err)
+	}
+
+	entries, err := os.ReadDir(filepath.Join(prefix, "bin"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read %s: %w", filepath.Join(prefix, "bin"), err)
+	}
+
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", binDir, err)
+	}
+
+	var links []string
+	for _, entry := range entries {
+		link := filepath.Join(binDir, entry.Name())
+		target := filepath.Join(currentLink(name), "bin", entry.Name())
+
+		if existing, err := os.Readlink(link); err == nil && existing == target {
+			links = append(links, link)
+			continue
+		} else if _, statErr := os.Lstat(link); statErr == nil && !r.ownsLink(name, link) {
+			r.logger.Warn(fmt.Sprintf("Not linking %s: %s already exists and was not created by devtool", entry.Name(), link))
+			continue
+		}
+
+		if err := switchSymlink(target, link); err != nil {
+			return nil, fmt.Errorf("failed to link %s: %w", link, err)
+		}
+		links = append(links, link)
+	}
+
+	for _, link := range previousLinks {
+		if !contains(links, link) && r.ownsLink(name, link) {
+			r.logger.Debug(fmt.Sprintf("Removing stale link %s", link))
+			os.Remove(link)
+		}
+	}
+
+	if len(links) > 0 && !dirOnPath(binDir) {
+		r.logger.Warn(fmt.Sprintf("%s is not on your PATH", binDir))
+	}
+
+	return links, nil
+}
+
+// ownsLink reports whether link is a symlink into the tool's opt directory.
+func (r *ToolRunner) ownsLink(name, link string) bool {
+	target, err := os.Readlink(link)
+	return err == nil && strings.HasPrefix(target, optDir(name)+string(filepath.Separator))
+}
+
+// switchSymlink atomically replaces link with a symlink to target.
+func switchSymlink(target, link string) error {
+	tmp := fmt.Sprintf("%s.devtool-%d", link, os.Getpid())
+	os.Remove(tmp)
+	if err := os.Symlink(target, tmp); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, link); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// prunePrefixes drops the oldest builds beyond the retention count, never the
+// ones in keep, and returns the builds that remain.
+func (r *ToolRunner) prunePrefixes(history []state.InstallPrefix, retain int, keep ...string) []state.InstallPrefix {
+	if retain < 1 {
+		retain = defaultRetain
+	}
+
+	excess := len(history) - retain
+	var kept []state.InstallPrefix
+	for _, entry := range history {
+		if excess > 0 && !contains(keep, entry.Path) {
+			r.logger.Debug(fmt.Sprintf("Removing old build %s", entry.Path))
+			if err := os.RemoveAll(entry.Path); err != nil {
+				r.logger.Warn(fmt.Sprintf("Failed to remove old build %s: %v", entry.Path, err))
+				kept = append(kept, entry)
+			}
+			excess--
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	return kept
+}
+
+// RollbackTool switches a source-built tool back to the build installed
+// before the active one.
+func (r *ToolRunner) RollbackTool(name string, toolConfig config.ToolConfig) error {
+	r.logger.Section(fmt.Sprintf("Rolling back %s", name))
+
+	if r.stateManager == nil {
+		return fmt.Errorf("state is required to roll back tools")
+	}
+
+	status, exists := r.stateManager.GetToolStatus(name)
+	if !exists || !status.Installed || status.Prefix == "" {
+		return fmt.Errorf("%s has no source builds managed by devtool", name)
+	}
+
+	active := -1
+	for i, entry := range status.Prefixes {
+		if entry.Path == status.Prefix {
+			active = i
+		}
+	}
+	if active < 1 {
+		return fmt.Errorf("no earlier build of %s to roll back to", name)
+	}
+
+	target := status.Prefixes[active-1]
+	if _, err := os.Stat(target.Path); err != nil {
+		return fmt.Errorf("previous build %s is missing", target.Path)
+	}
+
+	r.logger.Step(fmt.Sprintf("Switching %s from %s to %s", name, filepath.Base(status.Prefix), filepath.Base(target.Path)))
+
+	if r.dryRun {
+		r.logger.Info(fmt.Sprintf("[DRY RUN] Would point %s at %s", currentLink(name), target.Path))
+		return nil
+	}
+
+	buildConfig := toolConfig.BuildConfig
+	if buildConfig == nil {
+		buildConfig = &config.BuildConfig{}
+	}
+	status.RolledBack = true
+	if err := r.switchPrefix(name, buildConfig, status, target); err != nil {
+		return err
+	}
+
+	r.logger.Success(fmt.Sprintf("%s rolled back to %s", name, filepath.Base(target.Path)))
+	r.logger.Info(fmt.Sprintf("install keeps this build until you run devtool upgrade %s or install --rebuild", name))
+	return nil
+}
+
+// switchPrefix activates a retained build and records it as the active one.
+func (r *ToolRunner) switchPrefix(name string, buildConfig *config.BuildConfig, status state.ToolStatus, target state.InstallPrefix) error {
+	links, err := r.activatePrefix(name, target.Path, buildBinDir(buildConfig), status.Files)
+	if err != nil {
+		return err
+	}
+	r.recordPrefixUndo(name, target.Path, buildBinDir(buildConfig), status, links, false)
+	r.logger.Step(fmt.Sprintf("Activated %s build %s", name, filepath.Base(target.Path)))
+
+	status.Files = links
+	status.Prefix = target.Path
+	status.Version = target.Version
+	status.Commit = target.Commit
+	status.BuildHash = target.BuildHash
+	r.recordStateUndo(name)
+	r.stateManager.UpdateToolStatus(name, status)
+	if err := r.stateManager.Save(); err != nil {
+		r.logger.Warn(fmt.Sprintf("Failed to save state: %v", err))
+	}
+	return nil
+}
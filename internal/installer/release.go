@@ -0,0 +1,431 @@
+package installer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/lukeberry99/devtool/internal/config"
+	"github.com/lukeberry99/devtool/internal/state"
+)
+
+const defaultReleaseAPI = "https://api.github.com"
+
+type release struct {
+	TagName string         `json:"tag_name"`
+	Assets  []releaseAsset `json:"assets"`
+}
+
+type releaseAsset struct {
+	Name        string `json:"name"`
+	DownloadURL string `json:"browser_download_url"`
+}
+
+var (
+	osAliases = map[string][]string{
+		"darwin":  {"darwin", "macos", "mac", "apple", "osx"},
+		"linux":   {"linux"},
+		"windows": {"windows", "win"},
+	}
+	archAliases = map[string][]string{
+		"amd64": {"amd64", "x86_64", "x64", "64bit"},
+		"arm64": {"arm64", "aarch64"},
+		"386":   {"386", "i386", "i686", "x86", "32bit"},
+	}
+	// Assets with these suffixes are never the artifact itself
+	nonArtifactSuffixes = []string{
+		".sha256", ".sha256sum", ".sha512", ".md5", ".sig", ".asc", ".pem", ".sbom",
+		".json", ".txt", ".deb", ".rpm", ".apk", ".msi", ".pkg", ".dmg",
+	}
+	checksumLine = regexp.MustCompile(`^([0-9a-fA-F]{64})\s+\*?(.+)$`)
+)
+
+func (r *ToolRunner) installFromRelease(ctx context.Context, name string, toolConfig config.ToolConfig) error {
+	releaseConfig := toolConfig.ReleaseConfig
+	if releaseConfig == nil || releaseConfig.Repository == "" {
+		return fmt.Errorf("release configuration with a repository is required for release installs")
+	}
+
+	r.logger.Progress(fmt.Sprintf("Resolving %s release %s", releaseConfig.Repository, releaseVersion(toolConfig.Version)))
+
+	rel, err := r.resolveRelease(ctx, releaseConfig, toolConfig.Version)
+	if err != nil {
+		return err
+	}
+
+	data := newPlatformData(strings.TrimPrefix(rel.TagName, "v"), releaseConfig.ArchiveConfig)
+	data.Tag = rel.TagName
+
+	asset, err := selectReleaseAsset(rel, releaseConfig, data)
+	if err != nil {
+		return err
+	}
+	r.logger.Step(fmt.Sprintf("Selected %s from release %s", asset.Name, rel.TagName))
+
+	expected, err := r.releaseChecksum(ctx, rel, releaseConfig, asset, data)
+	if err != nil {
+		return err
+	}
+	if locked, ok := r.lockedChecksum(name); ok {
+		expected = locked
+	}
+
+	if err := r.declareDownload(ctx, asset.DownloadURL, expected, releaseConfig.ArchiveConfig); err != nil {
+		return err
+	}
+	if r.dryRun {
+		return nil
+	}
+
+	files, sum, err := r.fetchAndInstall(ctx, name, toolConfig, asset.DownloadURL, expected, releaseConfig.ArchiveConfig, data)
+	if err != nil {
+		return err
+	}
+
+	r.updateToolState(ctx, name, toolConfig, "release", func(status *state.ToolStatus) {
+		status.Version = rel.TagName
+		status.Files = files
+		status.BinaryPath = files[0]
+		status.URL = asset.DownloadURL
+		status.Checksum = sum
+	})
+
+	r.logger.Success(fmt.Sprintf("%s %s installed successfully", name, rel.TagName))
+	return nil
+}
+
+// isReleaseCurrent compares the recorded tag against the release the
+// configuration resolves to, so "latest" notices newly published releases.
+func (r *ToolRunner) isReleaseCurrent(ctx context.Context, name string, toolConfig config.ToolConfig, status state.ToolStatus) bool {
+	if toolConfig.ReleaseConfig == nil {
+		return false
+	}
+
+	if !isLatestRelease(toolConfig.Version) {
+		current, _ := recordedCurrent(status, toolConfig)
+		return current
+	}
+
+	rel, err := r.resolveRelease(ctx, toolConfig.ReleaseConfig, toolConfig.Version)
+	if err != nil {
+		r.logger.Warn(fmt.Sprintf("Could not check for a newer %s release, assuming current: %v", name, err))
+		return true
+	}
+
+	if rel.TagName != status.Version {
+		r.logger.Debug(fmt.Sprintf("Tool %s has a newer release: have %s, latest %s", name, status.Version, rel.TagName))
+		return false
+	}
+
+	r.logger.Debug(fmt.Sprintf("Tool %s is current (release %s)", name, rel.TagName))
+	return true
+}
+
+// resolveRelease turns "latest" or a tag into a concrete release. Tags are
+// tried as given and with a "v" prefix. Results are cached for the run.
+func (r *ToolRunner) resolveRelease(ctx context.Context, releaseConfig *config.ReleaseConfig, version string) (*release, error) {
+	cacheKey := releaseConfig.Repository + "@" + releaseVersion(version)
+	if cached, ok := r.releases.Load(cacheKey); ok {
+		return cached.(*release), nil
+	}
+
+	base := strings.TrimRight(releaseConfig.APIURL, "/")
+	if base == "" {
+		base = defaultReleaseAPI
+	}
+	repoURL := fmt.Sprintf("%s/repos/%s/releases", base, releaseConfig.Repository)
+
+	var candidates []string
+	if isLatestRelease(version) {
+		candidates = []string{repoURL + "/latest"}
+	} else {
+		candidates = []string{repoURL + "/tags/" + version}
+		if !strings.HasPrefix(version, "v") {
+			candidates = append(candidates, repoURL+"/tags/v"+version)
+		}
+	}
+
+	var lastErr error
+	for _, candidate := range candidates {
+		body, status, err := r.fetchReleaseAPI(ctx, releaseConfig, candidate)
+		if err != nil {
+			return nil, err
+		}
+		if status == http.StatusNotFound {
+			lastErr = fmt.Errorf("release %s not found for %s", releaseVersion(version), releaseConfig.Repository)
+			continue
+		}
+		if status != http.StatusOK {
+			return nil, fmt.Errorf("release API returned %d for %s", status, candidate)
+		}
+
+		var rel release
+		if err := json.Unmarshal(body, &rel); err != nil {
+			return nil, fmt.Errorf("failed to parse release response: %w", err)
+		}
+		if rel.TagName == "" {
+			return nil, fmt.Errorf("release response for %s has no tag_name", releaseConfig.Repository)
+		}
+
+		r.releases.Store(cacheKey, &rel)
+		return &rel, nil
+	}
+
+	return nil, lastErr
+}
+
+// fetchReleaseAPI queries the release API. GITHUB_TOKEN is only sent to
+// api.github.com, or to a configured api_url with send_github_token set.
+func (r *ToolRunner) fetchReleaseAPI(ctx context.Context, releaseConfig *config.ReleaseConfig, endpoint string) ([]byte, int, error) {
+	header := http.Header{"Accept": {"application/vnd.github+json"}}
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" && sendGitHubToken(releaseConfig, endpoint) {
+		header.Set("Authorization", "Bearer "+token)
+	}
+	return r.fetch(ctx, endpoint, header)
+}
+
+func sendGitHubToken(releaseConfig *config.ReleaseConfig, endpoint string) bool {
+	parsed, err := url.Parse(endpoint)
+	if err != nil || parsed.Scheme != "https" {
+		return false
+	}
+	if parsed.Host == "api.github.com" {
+		return true
+	}
+	if !releaseConfig.SendGitHubToken || releaseConfig.APIURL == "" {
+		return false
+	}
+	configured, err := url.Parse(releaseConfig.APIURL)
+	return err == nil && strings.EqualFold(configured.Host, parsed.Host)
+}
+
+// fetch GETs endpoint with header added and returns the body and status.
+func (r *ToolRunner) fetch(ctx context.Context, endpoint string, header http.Header) ([]byte, int, error) {
+	var body []byte
+	var status int
+	err := r.retry.do(ctx, r.logger, "GET "+endpoint, func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+		if err != nil {
+			return fmt.Errorf("invalid url %s: %w", endpoint, err)
+		}
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		req.Header.Set("User-Agent", "devtool")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("failed to fetch %s: %w", endpoint, err)
+		}
+		defer resp.Body.Close()
+
+		// Server errors and rate limiting are retried; other statuses are
+		// for the caller to interpret
+		if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+			return &httpStatusError{url: endpoint, status: resp.Status, code: resp.StatusCode}
+		}
+
+		if body, err = io.ReadAll(resp.Body); err != nil {
+			return fmt.Errorf("failed to read response from %s: %w", endpoint, err)
+		}
+		status = resp.StatusCode
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return body, status, nil
+}
+
+// selectReleaseAsset picks the asset for this machine, either by the
+// configured pattern or by scoring asset names for OS and architecture.
+func selectReleaseAsset(rel *release, releaseConfig *config.ReleaseConfig, data platformData) (releaseAsset, error) {
+	if releaseConfig.Asset != "" {
+		pattern, err := renderTemplate("asset", releaseConfig.Asset, data)
+		if err != nil {
+			return releaseAsset{}, err
+		}
+		for _, asset := range rel.Assets {
+			if ok, _ := path.Match(pattern, asset.Name); ok {
+				return asset, nil
+			}
+		}
+		return releaseAsset{}, fmt.Errorf("no asset in release %s matches %s", rel.TagName, pattern)
+	}
+
+	osNames := append([]string{strings.ToLower(data.OS)}, osAliases[runtime.GOOS]...)
+	archNames := append([]string{strings.ToLower(data.Arch)}, archAliases[runtime.GOARCH]...)
+	var universalNames []string
+	if runtime.GOOS == "darwin" {
+		universalNames = []string{"universal", "all"}
+	}
+
+	best, bestScore := []releaseAsset{}, 0
+	for _, asset := range rel.Assets {
+		score := scoreAsset(strings.ToLower(asset.Name), osNames, archNames, universalNames)
+		switch {
+		case score > bestScore:
+			best, bestScore = []releaseAsset{asset}, score
+		case score == bestScore && score > 0:
+			best = append(best, asset)
+		}
+	}
+
+	switch len(best) {
+	case 0:
+		return releaseAsset{}, fmt.Errorf("no asset in release %s matches %s/%s; set asset in the configuration", rel.TagName, runtime.GOOS, runtime.GOARCH)
+	case 1:
+		return best[0], nil
+	default:
+		names := make([]string, len(best))
+		for i, asset := range best {
+			names[i] = asset.Name
+		}
+		sort.Strings(names)
+		return releaseAsset{}, fmt.Errorf("several assets in release %s match %s/%s (%s); set asset in the configuration", rel.TagName, runtime.GOOS, runtime.GOARCH, strings.Join(names, ", "))
+	}
+}
+
+// scoreAsset returns 0 for assets that are not candidates for this platform,
+// and otherwise a score favouring exact arch matches and common archives.
+func scoreAsset(name string, osNames, archNames, universalNames []string) int {
+	for _, suffix := range nonArtifactSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return 0
+		}
+	}
+
+	if !containsToken(name, osNames) {
+		return 0
+	}
+
+	score := 10
+	switch {
+	case containsToken(name, archNames):
+		score += 10
+	case containsToken(name, universalNames):
+		score += 5
+	default:
+		return 0
+	}
+
+	switch detectArchiveFormat(name) {
+	case "tar.gz":
+		score += 3
+	case "tar.xz", "zip":
+		score += 2
+	case "tar.bz2", "tar":
+		score += 1
+	}
+
+	return score
+}
+
+// containsToken reports whether any token appears in name delimited by
+// non-alphanumeric characters, so "mac" does not match "machine".
+func containsToken(name string, tokens []string) bool {
+	for _, token := range tokens {
+		if token == "" {
+			continue
+		}
+		pattern := `(^|[^a-z0-9])` + regexp.QuoteMeta(strings.ToLower(token)) + `([^a-z0-9]|$)`
+		if regexp.MustCompile(pattern).MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// releaseChecksum finds the expected sha256 for asset from a checksum file
+// published in the same release. It returns "" when the release has none.
+func (r *ToolRunner) releaseChecksum(ctx context.Context, rel *release, releaseConfig *config.ReleaseConfig, asset releaseAsset, data platformData) (string, error) {
+	var checksumAsset *releaseAsset
+
+	if releaseConfig.ChecksumAsset != "" {
+		pattern, err := renderTemplate("checksum_asset", releaseConfig.ChecksumAsset, data)
+		if err != nil {
+			return "", err
+		}
+		for i := range rel.Assets {
+			if ok, _ := path.Match(pattern, rel.Assets[i].Name); ok {
+				checksumAsset = &rel.Assets[i]
+				break
+			}
+		}
+		if checksumAsset == nil {
+			return "", fmt.Errorf("no checksum asset in release %s matches %s", rel.TagName, pattern)
+		}
+	} else {
+		for i := range rel.Assets {
+			lower := strings.ToLower(rel.Assets[i].Name)
+			if lower == strings.ToLower(asset.Name)+".sha256" || lower == strings.ToLower(asset.Name)+".sha256sum" {
+				checksumAsset = &rel.Assets[i]
+				break
+			}
+			if checksumAsset == nil && (strings.Contains(lower, "checksums") || strings.Contains(lower, "sha256sums")) {
+				checksumAsset = &rel.Assets[i]
+			}
+		}
+		if checksumAsset == nil {
+			return "", nil
+		}
+	}
+
+	r.logger.Debug(fmt.Sprintf("Using checksums from %s", checksumAsset.Name))
+	body, status, err := r.fetch(ctx, checksumAsset.DownloadURL, nil)
+	if err != nil {
+		return "", err
+	}
+	if status != http.StatusOK {
+		return "", fmt.Errorf("failed to download %s: status %d", checksumAsset.Name, status)
+	}
+
+	sum, ok := parseChecksumFile(string(body), asset.Name)
+	if !ok {
+		return "", fmt.Errorf("%s has no checksum for %s", checksumAsset.Name, asset.Name)
+	}
+	return sum, nil
+}
+
+// parseChecksumFile reads sha256sum-style output. A file holding a single bare
+// hash applies to whatever asset it accompanies.
+func parseChecksumFile(contents, assetName string) (string, bool) {
+	lines := strings.Split(strings.TrimSpace(contents), "\n")
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if match := checksumLine.FindStringSubmatch(line); match != nil {
+			if path.Base(strings.TrimSpace(match[2])) == assetName {
+				return strings.ToLower(match[1]), true
+			}
+		}
+	}
+
+	if len(lines) == 1 {
+		fields := strings.Fields(lines[0])
+		if len(fields) == 1 && len(fields[0]) == 64 {
+			return strings.ToLower(fields[0]), true
+		}
+	}
+
+	return "", false
+}
+
+func isLatestRelease(version string) bool {
+	return version == "" || version == "latest"
+}
+
+func releaseVersion(version string) string {
+	if isLatestRelease(version) {
+		return "latest"
+	}
+	return version
+}
@@ -0,0 +1,32 @@
+package installer
+
+import (
+	"testing"
+
+	"github.com/lukeberry99/devtool/internal/config"
+)
+
+func TestSendGitHubToken(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   config.ReleaseConfig
+		endpoint string
+		want     bool
+	}{
+		{"github", config.ReleaseConfig{}, "https://api.github.com/repos/o/r/releases/latest", true},
+		{"github over http", config.ReleaseConfig{}, "http://api.github.com/repos/o/r/releases/latest", false},
+		{"custom api", config.ReleaseConfig{APIURL: "http://localhost:8080"}, "http://localhost:8080/repos/o/r/releases/latest", false},
+		{"enterprise without opt-in", config.ReleaseConfig{APIURL: "https://ghe.example.com/api/v3"}, "https://ghe.example.com/api/v3/repos/o/r/releases/latest", false},
+		{"enterprise with opt-in", config.ReleaseConfig{APIURL: "https://ghe.example.com/api/v3", SendGitHubToken: true}, "https://ghe.example.com/api/v3/repos/o/r/releases/latest", true},
+		{"opt-in elsewhere", config.ReleaseConfig{APIURL: "https://ghe.example.com/api/v3", SendGitHubToken: true}, "https://evil.example.net/repos/o/r/releases/latest", false},
+		{"lookalike host", config.ReleaseConfig{}, "https://api.github.com.evil.example/repos/o/r/releases/latest", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sendGitHubToken(&tt.config, tt.endpoint); got != tt.want {
+				t.Errorf("sendGitHubToken(%s) = %v, want %v", tt.endpoint, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,270 @@
+package installer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/lukeberry99/devtool/internal/config"
+	"github.com/lukeberry99/devtool/internal/executor"
+	"github.com/lukeberry99/devtool/internal/ui"
+)
+
+const (
+	defaultRetryAttempts   = 3
+	defaultRetryBackoff    = 2 * time.Second
+	defaultRetryMaxBackoff = 30 * time.Second
+	defaultRetryJitter     = 0.2
+)
+
+// defaultRetryPatterns are stderr fragments of git, curl and Homebrew that
+// point at a transient network problem rather than a real failure.
+var defaultRetryPatterns = []string{
+	"could not resolve host",
+	"couldn't resolve host",
+	"temporary failure in name resolution",
+	"connection reset",
+	"connection refused",
+	"timed out",
+	"failed to connect",
+	"network is unreachable",
+	"early eof",
+	"unexpected disconnect",
+	"rpc failed",
+	"the remote end hung up unexpectedly",
+	"tls handshake",
+	"ssl_error",
+	"gnutls",
+	"the requested url returned error: 5",
+	"the requested url returned error: 429",
+	"curl: (6)",
+	"curl: (7)",
+	"curl: (18)",
+	"curl: (28)",
+	"curl: (35)",
+	"curl: (52)",
+	"curl: (56)",
+}
+
+// retryPolicy decides whether and when a failed network-bound operation is
+// tried again.
+type retryPolicy struct {
+	attempts   int
+	backoff    time.Duration
+	maxBackoff time.Duration
+	jitter     float64
+	patterns   []string
+	exitCodes  []int
+}
+
+// newRetryPolicy merges a tool's retry settings over the global ones and fills
+// in the defaults.
+func newRetryPolicy(global config.RetryConfig, override *config.RetryConfig) retryPolicy {
+	policy := retryPolicy{
+		attempts:   defaultRetryAttempts,
+		backoff:    defaultRetryBackoff,
+		maxBackoff: defaultRetryMaxBackoff,
+		jitter:     defaultRetryJitter,
+		patterns:   append([]string{}, defaultRetryPatterns...),
+	}
+
+	for _, cfg := range []*config.RetryConfig{&global, override} {
+		if cfg == nil {
+			continue
+		}
+		if cfg.Attempts > 0 {
+			policy.attempts = cfg.Attempts
+		}
+		if cfg.Backoff > 0 {
+			policy.backoff = cfg.Backoff
+		}
+		if cfg.MaxBackoff > 0 {
+			policy.maxBackoff = cfg.MaxBackoff
+		}
+		if cfg.Jitter > 0 {
+			policy.jitter = cfg.Jitter
+		}
+		for _, pattern := range cfg.Patterns {
+			policy.patterns = append(policy.patterns, strings.ToLower(pattern))
+		}
+		policy.exitCodes = append(policy.exitCodes, cfg.ExitCodes...)
+	}
+
+	return policy
+}
+
+// commandError keeps the stderr of a failed command so the failure can be
+// classified.
+type commandError struct {
+	err    error
+	stderr string
+}
+
+func (e *commandError) Error() string { return e.err.Error() }
+func (e *commandError) Unwrap() error { return e.err }
+
+// httpStatusError is an HTTP response with an unexpected status.
+type httpStatusError struct {
+	url    string
+	status string
+	code   int
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("request to %s failed: %s", e.url, e.status)
+}
+
+// retryable reports whether err looks transient.
+func (p retryPolicy) retryable(err error) bool {
+	var timeoutErr *stepTimeoutError
+	if errors.As(err, &timeoutErr) {
+		return false
+	}
+
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.code >= 500 || statusErr.code == 429
+	}
+
+	var exitErr interface{ ExitCode() int }
+	if errors.As(err, &exitErr) {
+		for _, code := range p.exitCodes {
+			if exitErr.ExitCode() == code {
+				return true
+			}
+		}
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	text := strings.ToLower(err.Error())
+	var cmdErr *commandError
+	if errors.As(err, &cmdErr) {
+		text += "\n" + strings.ToLower(cmdErr.stderr)
+	}
+	for _, pattern := range p.patterns {
+		if strings.Contains(text, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// delay is the wait after the given failed attempt: the backoff doubled for
+// every earlier attempt, capped, then spread by the jitter fraction.
+func (p retryPolicy) delay(attempt int) time.Duration {
+	wait := p.backoff
+	for i := 1; i < attempt && wait < p.maxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > p.maxBackoff {
+		wait = p.maxBackoff
+	}
+	spread := (rand.Float64()*2 - 1) * p.jitter
+	return time.Duration(float64(wait) * (1 + spread))
+}
+
+// do runs op until it succeeds, fails in a way that is not retryable, the
+// attempts run out or ctx is cancelled. Every attempt is logged, and each gets
+// its own step timeout.
+func (p retryPolicy) do(ctx context.Context, logger *ui.Logger, description string, op func(context.Context) error) error {
+	for attempt := 1; ; attempt++ {
+		if attempt == 1 {
+			logger.Debug(fmt.Sprintf("Attempt 1/%d: %s", p.attempts, description))
+		} else {
+			logger.Step(fmt.Sprintf("Retrying %s (attempt %d/%d)", description, attempt, p.attempts))
+		}
+
+		stepCtx, cancel := stepContext(ctx)
+		err := stepError(ctx, stepCtx, op(stepCtx))
+		cancel()
+		if err == nil {
+			if attempt > 1 {
+				logger.Info(fmt.Sprintf("%s succeeded on attempt %d/%d", description, attempt, p.attempts))
+			}
+			return nil
+		}
+
+		if ctx.Err() != nil {
+			return err
+		}
+		if !p.retryable(err) {
+			logger.Debug(fmt.Sprintf("Attempt %d/%d of %s failed and is not retryable: %s", attempt, p.attempts, description, failureReason(err)))
+			return err
+		}
+		if attempt >= p.attempts {
+			logger.Warn(fmt.Sprintf("Attempt %d/%d of %s failed: %s; giving up", attempt, p.attempts, description, failureReason(err)))
+			return err
+		}
+
+		wait := p.delay(attempt)
+		logger.Warn(fmt.Sprintf("Attempt %d/%d of %s failed: %s; retrying in %s", attempt, p.attempts, description, failureReason(err), wait.Round(100*time.Millisecond)))
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// run runs the command built by newCmd through e under the policy. A fresh
+// command is built for every attempt; its stderr is kept, in addition to
+// wherever newCmd sends it, to classify failures.
+func (p retryPolicy) run(ctx context.Context, logger *ui.Logger, e executor.Executor, description string, newCmd func() executor.Command) error {
+	return p.do(ctx, logger, description, func(ctx context.Context) error {
+		cmd := newCmd()
+		var stderr tailBuffer
+		if cmd.Stderr != nil {
+			cmd.Stderr = io.MultiWriter(cmd.Stderr, &stderr)
+		} else {
+			cmd.Stderr = &stderr
+		}
+
+		if err := e.Run(ctx, cmd); err != nil {
+			return &commandError{err: err, stderr: stderr.String()}
+		}
+		return nil
+	})
+}
+
+// failureReason is the most telling line of a failure: the last line a
+// command wrote to stderr, or the error itself.
+func failureReason(err error) string {
+	if cmdErr, ok := err.(*commandError); ok {
+		lines := strings.Split(strings.TrimSpace(cmdErr.stderr), "\n")
+		if last := strings.TrimSpace(lines[len(lines)-1]); last != "" {
+			return last
+		}
+	}
+	return err.Error()
+}
+
+// tailBuffer keeps the last few kilobytes written to it.
+type tailBuffer struct {
+	data []byte
+}
+
+const tailBufferSize = 4096
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.data = append(b.data, p...)
+	if len(b.data) > tailBufferSize {
+		b.data = b.data[len(b.data)-tailBufferSize:]
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	return string(b.data)
+}
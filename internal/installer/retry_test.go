@@ -0,0 +1,54 @@
+package installer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lukeberry99/devtool/internal/config"
+	"github.com/lukeberry99/devtool/internal/ui"
+)
+
+func TestRetryable(t *testing.T) {
+	policy := newRetryPolicy(config.RetryConfig{}, nil)
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"transient stderr", &commandError{err: errors.New("exit status 128"), stderr: "fatal: unable to access: Operation timed out"}, true},
+		{"server error", &httpStatusError{code: 503}, true},
+		{"client error", &httpStatusError{code: 404}, false},
+		{"step timeout", &stepTimeoutError{timeout: time.Minute, err: &commandError{err: errors.New("signal: killed")}}, false},
+		{"step timeout of a network error", &stepTimeoutError{timeout: time.Minute, err: &commandError{err: errors.New("exit status 128"), stderr: "connection timed out"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.retryable(tt.err); got != tt.want {
+				t.Errorf("retryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStepTimeoutIsNotRetried(t *testing.T) {
+	policy := newRetryPolicy(config.RetryConfig{Attempts: 3, Backoff: time.Millisecond}, nil)
+	ctx := withStepTimeout(context.Background(), 10*time.Millisecond)
+
+	attempts := 0
+	err := policy.do(ctx, ui.NewLogger(false), "slow step", func(ctx context.Context) error {
+		attempts++
+		<-ctx.Done()
+		return &commandError{err: ctx.Err()}
+	})
+
+	var timeoutErr *stepTimeoutError
+	if !errors.As(err, &timeoutErr) {
+		t.Errorf("err = %v, want a step timeout", err)
+	}
+	if attempts != 1 {
+		t.Errorf("ran %d attempts, want 1", attempts)
+	}
+}
@@ -1,72 +1,239 @@
 package installer
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"os"
-	"os/exec"
-	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/lukeberry99/devtool/internal/config"
+	"github.com/lukeberry99/devtool/internal/executor"
+	"github.com/lukeberry99/devtool/internal/lockfile"
 	"github.com/lukeberry99/devtool/internal/state"
 	"github.com/lukeberry99/devtool/internal/ui"
+	"github.com/lukeberry99/devtool/internal/version"
 )
 
+// Options controls how a ToolRunner installs tools.
+type Options struct {
+	DryRun  bool
+	Verbose bool
+	Force   bool
+	// Rebuild runs source builds even when the checked-out commit and build
+	// steps match the last successful build.
+	Rebuild bool
+	// Jobs is the number of tools installed concurrently. Values below 1 are
+	// treated as 1.
+	Jobs int
+	// ConfigDir is the directory of the configuration file; script paths and
+	// working directories are resolved against it.
+	ConfigDir string
+	// Lock, when set, is the lockfile installs must reproduce exactly.
+	Lock *lockfile.Lockfile
+	// Atomic records an undo step for every change and unwinds them all when
+	// any tool fails.
+	Atomic bool
+	// Retry is the global retry policy for network-bound operations; tools
+	// can override it.
+	Retry config.RetryConfig
+	// Timeouts are the default limits for a whole tool and for a single
+	// command; tools can override them.
+	Timeouts config.TimeoutConfig
+	// Executor runs every external command; nil means this machine. Dry runs
+	// put a recorder in front of it.
+	Executor executor.Executor
+	// Homebrew holds the configured taps.
+	Homebrew config.HomebrewConfig
+}
+
 // Replicate the exact script execution logic from bash
 type ToolRunner struct {
 	logger       *ui.Logger
 	dryRun       bool
 	verbose      bool
 	force        bool
+	rebuild      bool
+	jobs         int
+	configDir    string
+	lock         *lockfile.Lockfile
+	tx           *transaction // nil unless the run is atomic
+	planner      *planner     // nil unless a plan is being computed
+	retryConfig  config.RetryConfig
+	retry        retryPolicy
+	timeouts     config.TimeoutConfig
+	executor     executor.Executor
 	homebrew     *HomebrewManager
+	brewConfig   config.HomebrewConfig
+	system       PackageManager // nil when the platform has no supported manager
+	systemErr    error
 	stateManager *state.LocalStateManager
 	detector     *state.ToolDetector
+	// releases caches resolved releases by "<repository>@<version>"
+	releases *sync.Map
 }
 
-func NewToolRunner(logger *ui.Logger, dryRun, verbose, force bool, stateManager *state.LocalStateManager) *ToolRunner {
-	homebrew := NewHomebrewManager(logger, dryRun)
+func NewToolRunner(logger *ui.Logger, stateManager *state.LocalStateManager, opts Options) *ToolRunner {
+	exec := opts.Executor
+	if exec == nil {
+		exec = executor.NewSystem()
+	}
+	if opts.DryRun {
+		exec = executor.NewRecorder(exec, func(cmd executor.Command) {
+			logger.Info(fmt.Sprintf("[DRY RUN] Would run: %s", cmd))
+		})
+	}
+
+	retry := newRetryPolicy(opts.Retry, nil)
+	homebrew := NewHomebrewManager(logger, exec).withRetry(retry).withTaps(opts.Homebrew.Taps)
+	system, systemErr := DetectSystemPackageManager(logger, exec, homebrew)
+
+	jobs := opts.Jobs
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	var tx *transaction
+	if opts.Atomic {
+		tx = newTransaction()
+	}
 
 	return &ToolRunner{
 		logger:       logger,
-		dryRun:       dryRun,
-		verbose:      verbose,
-		force:        force,
+		dryRun:       opts.DryRun,
+		verbose:      opts.Verbose,
+		force:        opts.Force,
+		rebuild:      opts.Rebuild,
+		jobs:         jobs,
+		configDir:    opts.ConfigDir,
+		lock:         opts.Lock,
+		tx:           tx,
+		retryConfig:  opts.Retry,
+		retry:        retry,
+		timeouts:     opts.Timeouts,
+		executor:     exec,
 		homebrew:     homebrew,
+		brewConfig:   opts.Homebrew,
+		system:       system,
+		systemErr:    systemErr,
 		stateManager: stateManager,
-		detector:     state.NewToolDetector(),
+		detector:     state.NewToolDetector(exec),
+		releases:     &sync.Map{},
+	}
+}
+
+// Executor returns the executor commands run through; in dry runs it is an
+// *executor.Recorder holding the commands that would have run.
+func (r *ToolRunner) Executor() executor.Executor {
+	return r.executor
+}
+
+// forTool returns the runner used to install a single tool. In parallel mode
+// its output is prefixed with the tool name so interleaved lines stay readable.
+func (r *ToolRunner) forTool(name string) *ToolRunner {
+	if r.jobs == 1 {
+		return r
+	}
+
+	clone := *r
+	clone.logger = r.logger.WithPrefix(name)
+	clone.homebrew = r.homebrew.withLogger(clone.logger)
+	if r.system != nil {
+		clone.system = r.system.WithLogger(clone.logger)
+	}
+	return &clone
+}
+
+// withRetry returns the runner to use for a tool that overrides the retry
+// policy.
+func (r *ToolRunner) withRetry(override *config.RetryConfig) *ToolRunner {
+	if override == nil {
+		return r
 	}
+
+	clone := *r
+	clone.retry = newRetryPolicy(r.retryConfig, override)
+	clone.homebrew = r.homebrew.withRetry(clone.retry)
+	return &clone
 }
 
-func (r *ToolRunner) InstallTool(name string, toolConfig config.ToolConfig) error {
+func (r *ToolRunner) InstallTool(ctx context.Context, name string, toolConfig config.ToolConfig) error {
+	r = r.withRetry(toolConfig.Retry)
 	r.logger.Section(fmt.Sprintf("Installing %s", name))
 
+	// Bound the whole install and every command it runs
+	timeout := toolConfig.Timeout
+	if timeout == 0 {
+		timeout = r.timeouts.Tool
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	step := toolConfig.StepTimeout
+	if step == 0 {
+		step = r.timeouts.Step
+	}
+	ctx = withStepTimeout(ctx, step)
+
+	if err := r.installTool(ctx, name, toolConfig); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("%s timed out after %s: %w", name, timeout, err)
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *ToolRunner) installTool(ctx context.Context, name string, toolConfig config.ToolConfig) error {
 	// Check if already installed and up-to-date
-	if r.isToolCurrent(name, toolConfig.InstalledBinary, toolConfig.Version, toolConfig) {
+	if r.isToolCurrent(ctx, name, toolConfig.InstalledBinary, toolConfig.Version, toolConfig) {
 		r.logger.Success(fmt.Sprintf("%s is already up to date", name))
-		return nil
+		if err := r.syncCurrentService(ctx, name, toolConfig); err != nil {
+			return err
+		}
+		return r.verifyLocked(name)
+	}
+
+	if r.planner != nil {
+		return r.planner.record(r.planAction(name, toolConfig), func() error {
+			return r.installFromSource(ctx, name, toolConfig)
+		})
+	}
+
+	if err := r.installFromSource(ctx, name, toolConfig); err != nil {
+		return err
 	}
+	return r.verifyLocked(name)
+}
 
+func (r *ToolRunner) installFromSource(ctx context.Context, name string, toolConfig config.ToolConfig) error {
 	switch toolConfig.Source {
 	case "homebrew":
-		return r.installFromHomebrew(name, toolConfig)
+		return r.installFromHomebrew(ctx, name, toolConfig)
+	case "system":
+		return r.installFromSystem(ctx, name, toolConfig)
 	case "build":
-		return r.buildFromSource(name, toolConfig)
+		return r.buildFromSource(ctx, name, toolConfig)
 	case "script":
-		return r.runCustomScript(name, toolConfig)
+		return r.runCustomScript(ctx, name, toolConfig)
+	case "download":
+		return r.installFromDownload(ctx, name, toolConfig)
+	case "release":
+		return r.installFromRelease(ctx, name, toolConfig)
+	case "go", "cargo", "pipx", "npm":
+		return r.installFromEcosystem(ctx, name, toolConfig)
 	default:
 		return fmt.Errorf("unknown installation source: %s", toolConfig.Source)
 	}
 }
 
-func (r *ToolRunner) runVersionCommand(toolName, command string) string {
-	if r.dryRun {
-		r.logger.Debug(fmt.Sprintf("[DRY RUN] Would run version command for %s: %s", toolName, command))
-		return "dry-run-version"
-	}
-
-	cmd := exec.Command("sh", "-c", command)
-	output, err := cmd.Output()
+// runVersionCommand runs the configured version command. It is user shell,
+// not a query devtool knows to be harmless, so dry runs only record it.
+func (r *ToolRunner) runVersionCommand(ctx context.Context, toolName, versionCommand string) string {
+	output, err := executor.Output(ctx, r.executor, executor.New("sh", "-c", versionCommand))
 	if err != nil {
 		r.logger.Debug(fmt.Sprintf("Version command failed for %s: %v", toolName, err))
 		return ""
@@ -75,10 +242,10 @@ func (r *ToolRunner) runVersionCommand(toolName, command string) string {
 	return strings.TrimSpace(string(output))
 }
 
-func (r *ToolRunner) detectActualVersion(name string, toolConfig config.ToolConfig, source string) string {
+func (r *ToolRunner) detectActualVersion(ctx context.Context, name string, toolConfig config.ToolConfig, source string) string {
 	// 1. Try custom version command first
 	if toolConfig.VersionCommand != "" {
-		if version := r.runVersionCommand(name, toolConfig.VersionCommand); version != "" {
+		if version := r.runVersionCommand(ctx, name, toolConfig.VersionCommand); version != "" {
 			r.logger.Debug(fmt.Sprintf("Detected %s version via custom command: %s", name, version))
 			return version
 		}
@@ -87,14 +254,31 @@ func (r *ToolRunner) detectActualVersion(name string, toolConfig config.ToolConf
 	// 2. Fall back to source-specific detection
 	switch source {
 	case "homebrew":
-		if version, err := r.homebrew.GetInstalledVersion(name); err == nil {
+		if version, err := r.homebrew.GetInstalledVersion(ctx, homebrewName(name, toolConfig)); err == nil {
 			r.logger.Debug(fmt.Sprintf("Detected %s version via Homebrew: %s", name, version))
 			return version
 		} else {
 			r.logger.Debug(fmt.Sprintf("Could not detect %s version via Homebrew: %v", name, err))
 		}
+	case "system":
+		if r.system != nil {
+			pkg := systemPackages(name, toolConfig, r.system)[0]
+			if version, err := r.system.GetInstalledVersion(ctx, pkg); err == nil {
+				r.logger.Debug(fmt.Sprintf("Detected %s version via %s: %s", name, r.system.Name(), version))
+				return version
+			} else {
+				r.logger.Debug(fmt.Sprintf("Could not detect %s version via %s: %v", name, r.system.Name(), err))
+			}
+		}
+	case "go", "cargo", "pipx", "npm":
+		if version, err := r.ecosystemVersion(ctx, name, toolConfig); err == nil {
+			r.logger.Debug(fmt.Sprintf("Detected %s version via %s: %s", name, source, version))
+			return version
+		} else {
+			r.logger.Debug(fmt.Sprintf("Could not detect %s version via %s: %v", name, source, err))
+		}
 	case "build", "script":
-		if version, err := r.detector.GetVersion(name); err == nil {
+		if version, err := r.detector.GetVersion(ctx, name); err == nil {
 			r.logger.Debug(fmt.Sprintf("Detected %s version via tool detection: %s", name, version))
 			return version
 		} else {
@@ -110,7 +294,7 @@ func (r *ToolRunner) detectActualVersion(name string, toolConfig config.ToolConf
 func (r *ToolRunner) validateToolExists(name string, config config.ToolConfig) bool {
 	return r.detector.IsInstalled(name, config)
 }
-func (r *ToolRunner) isToolCurrent(name, binary, expectedVersion string, config config.ToolConfig) bool {
+func (r *ToolRunner) isToolCurrent(ctx context.Context, name, binary, expectedVersion string, config config.ToolConfig) bool {
 	if r.force {
 		r.logger.Debug(fmt.Sprintf("Force mode enabled - will reinstall %s", name))
 		return false // --force flag bypasses all checks
@@ -126,15 +310,28 @@ func (r *ToolRunner) isToolCurrent(name, binary, expectedVersion string, config
 		return false
 	}
 
-	// If config doesn't specify version, any installed version is OK
-	if expectedVersion == "" {
-		r.logger.Debug(fmt.Sprintf("Tool %s is current (no version requirement)", name))
-		return true
+	// Releases track a moving "latest", so ask the release API
+	if config.Source == "release" {
+		return r.isReleaseCurrent(ctx, name, config, status)
+	}
+
+	// Channels follow upstream. Source builds and language package managers
+	// check upstream themselves; other sources accept what is installed.
+	if expectedVersion != "" && version.IsChannel(expectedVersion) {
+		switch config.Source {
+		case "build", "go", "cargo", "pipx", "npm":
+			r.logger.Debug(fmt.Sprintf("Tool %s tracks %s, checking upstream", name, expectedVersion))
+			return false
+		}
 	}
 
-	// Strict version matching when config specifies version
-	if status.Version == expectedVersion {
-		r.logger.Debug(fmt.Sprintf("Tool %s is current (version %s matches)", name, expectedVersion))
+	current, err := recordedCurrent(status, config)
+	if err != nil {
+		r.logger.Warn(fmt.Sprintf("Tool %s: %v", name, err))
+		return false
+	}
+	if current {
+		r.logger.Debug(fmt.Sprintf("Tool %s is current (have %s, want %s)", name, status.Version, expectedVersion))
 		return true
 	}
 
@@ -154,10 +351,55 @@ func (r *ToolRunner) isToolCurrent(name, binary, expectedVersion string, config
 	return false
 }
 
-func (r *ToolRunner) installFromHomebrew(name string, toolConfig config.ToolConfig) error {
-	if toolConfig.Cask {
+// recordedCurrent reports whether a recorded install meets the configured
+// version by the rules install follows, without asking upstream: any version
+// meets a channel, source builds pinned to a commit need that commit, and
+// everything else must satisfy the version or constraint.
+func recordedCurrent(status state.ToolStatus, toolConfig config.ToolConfig) (bool, error) {
+	wanted := toolConfig.Version
+	switch {
+	case version.IsChannel(wanted), status.Version == wanted:
+		return true, nil
+	case toolConfig.Source == "build" && version.IsCommit(wanted):
+		return strings.HasPrefix(status.Commit, wanted), nil
+	}
+	return version.Satisfies(status.Version, wanted)
+}
+
+func (r *ToolRunner) installFromHomebrew(ctx context.Context, name string, toolConfig config.ToolConfig) error {
+	formula := homebrewName(name, toolConfig)
+	cask := toolConfig.Cask || contains(toolConfig.HomebrewArgs, "--cask")
+
+	if toolConfig.Tap != "" {
+		if err := r.ensureTap(ctx, toolConfig.Tap); err != nil {
+			return err
+		}
+	}
+
+	previous, _ := r.homebrew.GetInstalledVersion(ctx, formula)
+
+	if r.tx != nil && !r.homebrew.IsPackageInstalled(ctx, formula) {
+		defer func() {
+			if r.homebrew.IsPackageInstalled(ctx, formula) {
+				r.recordUndo(name, fmt.Sprintf("brew uninstall %s", formula), func(ctx context.Context) error {
+					if cask {
+						return r.homebrew.UninstallCask(ctx, formula)
+					}
+					return r.homebrew.UninstallPackages(ctx, []string{formula})
+				})
+			}
+		}()
+	}
+
+	if cask {
 		r.logger.Progress(fmt.Sprintf("Installing %s app from Homebrew", name))
-		if err := r.homebrew.InstallCask(name); err != nil {
+		var args []string
+		for _, arg := range toolConfig.HomebrewArgs {
+			if arg != "--cask" {
+				args = append(args, arg)
+			}
+		}
+		if err := r.homebrew.InstallCask(ctx, formula, args...); err != nil {
 			return err
 		}
 	} else {
@@ -165,98 +407,154 @@ func (r *ToolRunner) installFromHomebrew(name string, toolConfig config.ToolConf
 
 		// Install the package with any specified arguments
 		if len(toolConfig.HomebrewArgs) > 0 {
-			if err := r.homebrew.InstallPackageWithArgs(name, toolConfig.HomebrewArgs); err != nil {
+			if err := r.homebrew.InstallPackageWithArgs(ctx, formula, toolConfig.HomebrewArgs); err != nil {
 				return err
 			}
 		} else {
-			if err := r.homebrew.InstallPackages([]string{name}); err != nil {
+			if err := r.homebrew.InstallPackages(ctx, []string{formula}); err != nil {
 				return err
 			}
 		}
 	}
 
 	// Update state tracking
-	r.updateToolState(name, toolConfig, "homebrew")
+	r.updateToolState(ctx, name, toolConfig, "homebrew", func(status *state.ToolStatus) {
+		status.Tap = toolConfig.Tap
+		status.Cask = cask
+	})
+
+	current, _ := r.homebrew.GetInstalledVersion(ctx, formula)
+	if err := r.syncService(ctx, name, toolConfig, previous != "" && current != previous); err != nil {
+		return err
+	}
 
 	r.logger.Success(fmt.Sprintf("%s installed successfully", name))
 	return nil
 }
 
-func (r *ToolRunner) buildFromSource(name string, toolConfig config.ToolConfig) error {
-	r.logger.Info(fmt.Sprintf("Building %s from source", name))
-
-	if toolConfig.BuildConfig == nil {
-		return fmt.Errorf("build configuration is required for source builds")
+// batchHomebrew installs the missing plain formulae among tools with one brew
+// call before the per-tool installs run. Those then find them installed, and
+// install whatever the batch failed on one at a time, so failures are still
+// reported against the right tool. Formulae that depend on tools from other
+// sources wait for their turn.
+func (r *ToolRunner) batchHomebrew(ctx context.Context, tools map[string]config.ToolConfig) {
+	// Plans hold each tool's own commands, so plans are made and applied one
+	// formula at a time
+	if r.dryRun || r.planner != nil {
+		return
 	}
-
-	buildConfig := toolConfig.BuildConfig
-	r.logger.Info(fmt.Sprintf("[BUILD] Building %s from %s", name, buildConfig.Repository))
-
-	if r.dryRun {
-		r.logger.Info(fmt.Sprintf("[DRY RUN] Would build %s from source", name))
-		return nil
+	if _, ok := r.executor.(*executor.Verifier); ok {
+		return
 	}
 
-	// 1. Install dependencies if specified
-	if len(buildConfig.Dependencies) > 0 {
-		if err := r.InstallDependencies(buildConfig.Dependencies); err != nil {
-			return fmt.Errorf("failed to install dependencies for %s: %w", name, err)
+	var names, formulae []string
+	for _, name := range sortedToolNames(tools) {
+		toolConfig := tools[name]
+		if toolConfig.Source != "homebrew" || toolConfig.Cask || len(toolConfig.HomebrewArgs) > 0 {
+			continue
+		}
+		if !onlyHomebrewDependencies(name, tools, map[string]bool{}) {
+			continue
+		}
+		if r.isToolCurrent(ctx, name, toolConfig.InstalledBinary, toolConfig.Version, toolConfig) {
+			continue
+		}
+		formula := homebrewName(name, toolConfig)
+		if r.homebrew.IsPackageInstalled(ctx, formula) {
+			continue
 		}
+		names = append(names, name)
+		formulae = append(formulae, formula)
 	}
-
-	// 2. Prepare repository
-	repoDir, err := r.prepareRepository(name, buildConfig.Repository, toolConfig.Version)
-	if err != nil {
-		return fmt.Errorf("failed to prepare repository for %s: %w", name, err)
+	if len(formulae) < 2 {
+		return
 	}
 
-	// 3. Execute build steps
-	if err := r.executeBuildSteps(name, repoDir, buildConfig.BuildSteps); err != nil {
-		return fmt.Errorf("failed to build %s: %w", name, err)
+	r.logger.Progress(fmt.Sprintf("Installing %d formulae from Homebrew: %s", len(formulae), strings.Join(formulae, " ")))
+	if err := r.homebrew.InstallBatch(ctx, formulae); err != nil {
+		r.logger.Warn(fmt.Sprintf("Installing the formulae at once failed, installing them one at a time: %v", err))
 	}
 
-	// 4. Execute install steps
-	if err := r.executeInstallSteps(name, repoDir, buildConfig.InstallSteps); err != nil {
-		return fmt.Errorf("failed to install %s: %w", name, err)
+	for i, formula := range formulae {
+		if r.homebrew.IsPackageInstalled(ctx, formula) {
+			r.recordUndo(names[i], fmt.Sprintf("brew uninstall %s", formula), func(ctx context.Context) error {
+				return r.homebrew.UninstallPackages(ctx, []string{formula})
+			})
+		}
 	}
+}
 
-	// Update state tracking
-	r.updateToolState(name, toolConfig, "built_from_source")
-
-	r.logger.Info(fmt.Sprintf("✅ %s built and installed successfully", name))
-	return nil
+// onlyHomebrewDependencies reports whether every configured tool name depends
+// on, directly or not, is installed from Homebrew.
+func onlyHomebrewDependencies(name string, tools map[string]config.ToolConfig, seen map[string]bool) bool {
+	for _, dep := range tools[name].Dependencies {
+		depConfig, ok := tools[dep]
+		if !ok || seen[dep] {
+			continue
+		}
+		seen[dep] = true
+		if depConfig.Source != "homebrew" || !onlyHomebrewDependencies(dep, tools, seen) {
+			return false
+		}
+	}
+	return true
 }
 
-func (r *ToolRunner) runCustomScript(name string, toolConfig config.ToolConfig) error {
-	r.logger.Info(fmt.Sprintf("Running custom script for %s", name))
+func (r *ToolRunner) installFromSystem(ctx context.Context, name string, toolConfig config.ToolConfig) error {
+	if r.system == nil {
+		return r.systemErr
+	}
 
-	if r.dryRun {
-		r.logger.Info(fmt.Sprintf("[DRY RUN] Would run custom script for %s", name))
-		return nil
+	packages := systemPackages(name, toolConfig, r.system)
+	r.logger.Progress(fmt.Sprintf("Installing %s with %s", name, r.system.Name()))
+
+	var missing []string
+	for _, pkg := range packages {
+		if r.system.IsPackageInstalled(ctx, pkg) {
+			r.logger.Debug(fmt.Sprintf("Package %s already installed", pkg))
+			continue
+		}
+		missing = append(missing, pkg)
 	}
 
-	// TODO: Implement custom script execution
-	r.logger.Warn(fmt.Sprintf("Custom script execution not yet implemented for %s", name))
+	if len(missing) > 0 {
+		if err := r.system.InstallPackages(ctx, missing); err != nil {
+			return err
+		}
+		system := r.system
+		r.recordUndo(name, fmt.Sprintf("%s remove %s", system.Name(), strings.Join(missing, " ")), func(ctx context.Context) error {
+			return system.UninstallPackages(ctx, missing)
+		})
+	}
 
 	// Update state tracking
-	r.updateToolState(name, toolConfig, "script")
+	r.updateToolState(ctx, name, toolConfig, "system")
 
+	r.logger.Success(fmt.Sprintf("%s installed successfully", name))
 	return nil
 }
 
-func (r *ToolRunner) updateToolState(name string, toolConfig config.ToolConfig, source string) {
-	if r.stateManager == nil || r.dryRun {
+// updateToolState records a successful install. Sources that know more than
+// the generic detection can fill in the status through details.
+func (r *ToolRunner) updateToolState(ctx context.Context, name string, toolConfig config.ToolConfig, source string, details ...func(*state.ToolStatus)) {
+	if r.stateManager == nil {
+		return
+	}
+	if r.dryRun {
+		// Detection may run commands that are not read-only, such as
+		// version_command; record them so plans allow them
+		r.detectActualVersion(ctx, name, toolConfig, source)
 		return
 	}
 
 	now := time.Now()
 
 	// Detect actual installed version using new enhanced detection
-	actualVersion := r.detectActualVersion(name, toolConfig, source)
+	actualVersion := r.detectActualVersion(ctx, name, toolConfig, source)
 
 	// Detect binary path
 	binaryPath := ""
-	if path, err := exec.LookPath(name); err == nil {
+	if path, err := r.executor.LookPath(name); err == nil {
 		binaryPath = path
 		r.logger.Debug(fmt.Sprintf("Found %s at: %s", name, binaryPath))
 	}
@@ -270,7 +568,11 @@ func (r *ToolRunner) updateToolState(name string, toolConfig config.ToolConfig,
 		BinaryPath:    binaryPath,
 		ConfigCurrent: true, // TODO: Compare actualVersion with toolConfig.Version
 	}
+	for _, detail := range details {
+		detail(&toolStatus)
+	}
 
+	r.recordStateUndo(name)
 	r.stateManager.UpdateToolStatus(name, toolStatus)
 
 	// Save state to disk
@@ -279,244 +581,139 @@ func (r *ToolRunner) updateToolState(name string, toolConfig config.ToolConfig,
 	}
 }
 
-func (r *ToolRunner) InstallDependencies(dependencies []string) error {
+func (r *ToolRunner) InstallDependencies(ctx context.Context, dependencies []string) error {
 	if len(dependencies) == 0 {
 		return nil
 	}
 
 	r.logger.Info(fmt.Sprintf("Installing dependencies: %v", dependencies))
 
-	return r.homebrew.InstallPackages(dependencies)
+	if r.system == nil {
+		return r.systemErr
+	}
+	return r.system.InstallPackages(ctx, dependencies)
 }
 
-func (r *ToolRunner) InstallTools(tools map[string]config.ToolConfig) error {
-	// Filter enabled tools and check if any need Homebrew
+func (r *ToolRunner) InstallTools(ctx context.Context, tools map[string]config.ToolConfig) error {
+	// Filter enabled tools and work out which package managers they need
 	enabledTools := make(map[string]config.ToolConfig)
-	needsHomebrew := false
-
-	for name, toolConfig := range tools {
-		if toolConfig.Enabled {
-			enabledTools[name] = toolConfig
-			if toolConfig.Source == "homebrew" {
-				needsHomebrew = true
+	var managers []PackageManager
+	var taps []string
+	needs := func(manager PackageManager) {
+		for _, m := range managers {
+			if m == manager {
+				return
 			}
 		}
+		managers = append(managers, manager)
 	}
 
-	if len(enabledTools) == 0 {
-		r.logger.Info("No tools enabled for installation")
-		return nil
-	}
-
-	r.logger.Section(fmt.Sprintf("📦 Installing %d tools", len(enabledTools)))
-
-	// Ensure Homebrew once upfront if any tools need it
-	if needsHomebrew {
-		r.logger.Step("Setting up Homebrew...")
-		if err := r.homebrew.EnsureInstalled(); err != nil {
-			return fmt.Errorf("failed to ensure Homebrew is installed: %w", err)
-		}
-	}
-
-	// Install each tool
-	successCount := 0
-	for name, toolConfig := range enabledTools {
-		if err := r.InstallTool(name, toolConfig); err != nil {
-			r.logger.Error(fmt.Sprintf("Failed to install %s: %v", name, err))
+	for _, name := range sortedToolNames(tools) {
+		toolConfig := tools[name]
+		if !toolConfig.Enabled {
 			continue
 		}
-		successCount++
-	}
+		enabledTools[name] = toolConfig
 
-	// Cleanup Homebrew if configured
-	if needsHomebrew {
-		r.logger.Step("Cleaning up Homebrew...")
-		if err := r.homebrew.Cleanup(); err != nil {
-			r.logger.Warn(fmt.Sprintf("Failed to cleanup Homebrew: %v", err))
+		switch {
+		case toolConfig.Source == "homebrew":
+			needs(r.homebrew)
+			if toolConfig.Tap != "" {
+				taps = append(taps, toolConfig.Tap)
+			}
+		case toolConfig.Source == "system", toolConfig.BuildConfig != nil && len(toolConfig.BuildConfig.Dependencies) > 0:
+			if r.system != nil {
+				needs(r.system)
+			}
 		}
 	}
 
-	// Show summary
-	if successCount == len(enabledTools) {
-		r.logger.Success(fmt.Sprintf("All %d tools installed successfully", successCount))
-	} else {
-		failed := len(enabledTools) - successCount
-		r.logger.Warn(fmt.Sprintf("%d tools installed, %d failed", successCount, failed))
+	if len(enabledTools) == 0 {
+		r.logger.Info("No tools enabled for installation")
+		return nil
 	}
 
-	return nil
-}
-
-func (r *ToolRunner) prepareRepository(name, repository, version string) (string, error) {
-	r.logger.Info(fmt.Sprintf("Preparing %s repository...", name))
-
-	// Create build directory
-	homeDir, _ := os.UserHomeDir()
-	buildDir := filepath.Join(homeDir, ".devtool", "builds", name)
-	if err := os.MkdirAll(buildDir, 0755); err != nil {
-		return "", fmt.Errorf("failed to create build directory: %w", err)
+	// Resolve dependency order before touching anything
+	order, err := resolveInstallOrder(enabledTools)
+	if err != nil {
+		return err
 	}
+	r.warnUnmanagedDependencies(enabledTools, tools)
 
-	repoPath := filepath.Join(buildDir, name)
-
-	// Check if repository already exists
-	if _, err := os.Stat(filepath.Join(repoPath, ".git")); err == nil {
-		r.logger.Debug("Repository exists, updating...")
-		if err := r.updateRepository(repoPath, version); err != nil {
-			return "", err
-		}
-	} else {
-		// Clone repository
-		r.logger.Info(fmt.Sprintf("Cloning %s repository...", name))
-		cmd := exec.Command("git", "clone", repository, repoPath)
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
+	r.logger.Section(fmt.Sprintf("📦 Installing %d tools", len(enabledTools)))
 
-		if err := cmd.Run(); err != nil {
-			return "", fmt.Errorf("failed to clone repository: %w", err)
+	// Ensure each package manager once upfront
+	for _, manager := range managers {
+		r.logger.Step(fmt.Sprintf("Setting up %s...", manager.Name()))
+		if err := manager.EnsureInstalled(ctx); err != nil {
+			return r.abort(fmt.Errorf("failed to ensure %s is installed: %w", manager.Name(), err))
 		}
-
-		// Checkout appropriate version
-		if err := r.checkoutVersion(repoPath, version); err != nil {
-			return "", err
+		if manager == PackageManager(r.homebrew) {
+			if err := r.ensureTaps(ctx, taps); err != nil {
+				return r.abort(err)
+			}
 		}
 	}
 
-	return repoPath, nil
-}
-
-func (r *ToolRunner) updateRepository(repoPath, version string) error {
-	// Change to repository directory
-	originalDir, err := os.Getwd()
-	if err != nil {
-		return fmt.Errorf("failed to get current directory: %w", err)
-	}
-	defer os.Chdir(originalDir)
-
-	if err := os.Chdir(repoPath); err != nil {
-		return fmt.Errorf("failed to change to repository directory: %w", err)
-	}
-
-	// Fetch latest changes
-	cmd := exec.Command("git", "fetch", "origin")
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to fetch updates: %w", err)
-	}
-
-	return r.checkoutVersion(repoPath, version)
-}
-
-func (r *ToolRunner) checkoutVersion(repoPath, version string) error {
-	originalDir, err := os.Getwd()
-	if err != nil {
-		return fmt.Errorf("failed to get current directory: %w", err)
-	}
-	defer os.Chdir(originalDir)
-
-	if err := os.Chdir(repoPath); err != nil {
-		return fmt.Errorf("failed to change to repository directory: %w", err)
-	}
-
-	var target string
-	switch version {
-	case "nightly", "":
-		target = "master"
-	case "stable":
-		target = "stable"
-	default:
-		target = version
-	}
-
-	r.logger.Debug(fmt.Sprintf("Checking out %s", target))
-	cmd := exec.Command("git", "checkout", target)
-
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to checkout %s: %w", target, err)
-	}
+	// Install tools in dependency order, skipping dependents of failures
+	r.batchHomebrew(ctx, enabledTools)
+	summary := r.installInOrder(ctx, order, enabledTools)
 
-	// Pull latest changes if on a branch
-	if target == "master" || target == "stable" {
-		cmd = exec.Command("git", "pull", "origin", target)
-		if err := cmd.Run(); err != nil {
-			r.logger.Warn(fmt.Sprintf("Failed to pull latest changes: %v", err))
+	// Cleanup package managers, unless the run was cancelled
+	for _, manager := range managers {
+		if ctx.Err() != nil {
+			break
+		}
+		if err := manager.Cleanup(ctx); err != nil {
+			r.logger.Warn(fmt.Sprintf("Failed to cleanup %s: %v", manager.Name(), err))
 		}
 	}
 
-	return nil
-}
-
-func (r *ToolRunner) executeBuildSteps(name, repoDir string, buildSteps []string) error {
-	if len(buildSteps) == 0 {
-		r.logger.Debug(fmt.Sprintf("No build steps defined for %s", name))
+	// Show summary
+	if summary.succeeded == len(enabledTools) {
+		r.logger.Success(fmt.Sprintf("All %d tools installed successfully", summary.succeeded))
+		if r.tx != nil {
+			r.tx.discard()
+		}
 		return nil
 	}
 
-	r.logger.Info(fmt.Sprintf("Building %s...", name))
-
-	originalDir, err := os.Getwd()
-	if err != nil {
-		return fmt.Errorf("failed to get current directory: %w", err)
-	}
-	defer os.Chdir(originalDir)
-
-	if err := os.Chdir(repoDir); err != nil {
-		return fmt.Errorf("failed to change to repository directory: %w", err)
-	}
-
-	// Clean previous builds if make is involved
-	if len(buildSteps) > 0 {
-		cmd := exec.Command("make", "distclean")
-		cmd.Run() // Ignore errors for clean
-	}
-
-	// Execute each build step
-	for i, step := range buildSteps {
-		r.logger.Info(fmt.Sprintf("Executing build step %d/%d: %s", i+1, len(buildSteps), step))
+	r.logger.Warn(fmt.Sprintf("%d tools installed, %d failed, %d skipped", summary.succeeded, summary.failed, summary.skipped))
 
-		cmd := exec.Command("sh", "-c", step)
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-		cmd.Dir = repoDir
-
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("build step %d failed: %w", i+1, err)
+	interrupted := ctx.Err() != nil
+	if interrupted {
+		if len(summary.interrupted) > 0 {
+			r.logger.Warn(fmt.Sprintf("Interrupted: %s", strings.Join(summary.interrupted, ", ")))
+		}
+		if len(summary.notStarted) > 0 {
+			r.logger.Warn(fmt.Sprintf("Not started: %s", strings.Join(summary.notStarted, ", ")))
 		}
 	}
 
-	return nil
-}
-
-func (r *ToolRunner) executeInstallSteps(name, repoDir string, installSteps []string) error {
-	if len(installSteps) == 0 {
-		r.logger.Debug(fmt.Sprintf("No install steps defined for %s", name))
-		return nil
+	// An atomic run leaves nothing behind when it fails. The run's context may
+	// already be cancelled, so the rollback gets its own.
+	if r.tx != nil {
+		if failed := r.tx.rollback(context.Background(), r.logger); failed > 0 {
+			return fmt.Errorf("installation failed and %d changes could not be rolled back", failed)
+		}
+		if interrupted {
+			return fmt.Errorf("installation interrupted; all changes were rolled back")
+		}
+		return fmt.Errorf("installation failed; all changes were rolled back")
 	}
-
-	r.logger.Info(fmt.Sprintf("Installing %s...", name))
-
-	originalDir, err := os.Getwd()
-	if err != nil {
-		return fmt.Errorf("failed to get current directory: %w", err)
+	if interrupted {
+		if r.stateManager != nil && !r.dryRun {
+			if err := r.stateManager.Save(); err != nil {
+				r.logger.Warn(fmt.Sprintf("Failed to save state: %v", err))
+			}
+		}
+		return fmt.Errorf("installation interrupted")
 	}
-	defer os.Chdir(originalDir)
-
-	if err := os.Chdir(repoDir); err != nil {
-		return fmt.Errorf("failed to change to repository directory: %w", err)
+	if r.lock != nil {
+		return fmt.Errorf("%d tools could not be installed as locked", summary.failed+summary.skipped)
 	}
-
-	// Execute each install step
-	for i, step := range installSteps {
-		r.logger.Info(fmt.Sprintf("Executing install step %d/%d: %s", i+1, len(installSteps), step))
-
-		cmd := exec.Command("sh", "-c", step)
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-		cmd.Stdin = os.Stdin // For sudo password prompts
-		cmd.Dir = repoDir
-
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("install step %d failed: %w", i+1, err)
-		}
+	if _, applying := r.executor.(*executor.Verifier); applying {
+		return fmt.Errorf("%d tools of the plan could not be installed", summary.failed+summary.skipped)
 	}
 
 	return nil
@@ -0,0 +1,114 @@
+package installer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lukeberry99/devtool/internal/config"
+	"github.com/lukeberry99/devtool/internal/executor"
+	"github.com/lukeberry99/devtool/internal/state"
+	"github.com/lukeberry99/devtool/internal/ui"
+)
+
+const (
+	brewInfo    = "brew info --json=v2 --installed"
+	brewNothing = `{"formulae":[],"casks":[]}`
+	brewJQ      = `{"formulae":[{"name":"jq","full_name":"jq","aliases":[],"oldnames":[],"linked_keg":"1.7.1","installed":[{"version":"1.7.1"}]}],"casks":[]}`
+)
+
+func newTestRunner(t *testing.T, fake *executor.Fake, opts Options) (*ToolRunner, *state.LocalStateManager) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	stateManager, err := state.NewLocalStateManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts.Executor = fake
+	return NewToolRunner(ui.NewLogger(false), stateManager, opts), stateManager
+}
+
+func TestInstallFromHomebrew(t *testing.T) {
+	fake := executor.NewFake().
+		Path("brew", "/opt/homebrew/bin/brew").
+		On(brewInfo, executor.Response{Stdout: brewNothing}, executor.Response{Stdout: brewJQ}).
+		On("brew install jq").
+		On("jq --version", executor.Response{Stdout: "jq-1.7.1\n"})
+	runner, stateManager := newTestRunner(t, fake, Options{})
+
+	toolConfig := config.ToolConfig{Source: "homebrew", Enabled: true}
+	if err := runner.InstallTool(context.Background(), "jq", toolConfig); err != nil {
+		t.Fatalf("InstallTool: %v\ncommands: %q", err, fake.Calls())
+	}
+
+	installs := 0
+	for _, call := range fake.Calls() {
+		if call == "brew install jq" {
+			installs++
+		}
+	}
+	if installs != 1 {
+		t.Errorf("brew install jq ran %d times, want once: %q", installs, fake.Calls())
+	}
+
+	status, ok := stateManager.GetToolStatus("jq")
+	if !ok || !status.Installed {
+		t.Fatalf("jq is not recorded as installed: %+v", status)
+	}
+	if status.Source != "homebrew" || status.Version != "1.7.1" {
+		t.Errorf("state = source %q, version %q; want homebrew, 1.7.1", status.Source, status.Version)
+	}
+}
+
+func TestInstallFromHomebrewAlreadyInstalled(t *testing.T) {
+	fake := executor.NewFake().
+		Path("brew", "/opt/homebrew/bin/brew").
+		On(brewInfo, executor.Response{Stdout: brewJQ}).
+		On("jq --version", executor.Response{Stdout: "jq-1.7.1\n"})
+	runner, _ := newTestRunner(t, fake, Options{})
+
+	// The fake fails any brew install, so this only passes if none runs
+	if err := runner.InstallTool(context.Background(), "jq", config.ToolConfig{Source: "homebrew", Enabled: true}); err != nil {
+		t.Fatalf("InstallTool: %v\ncommands: %q", err, fake.Calls())
+	}
+}
+
+func TestDryRunRecordsCommands(t *testing.T) {
+	fake := executor.NewFake().
+		Path("brew", "/opt/homebrew/bin/brew").
+		On(brewInfo, executor.Response{Stdout: brewNothing}).
+		On("brew tap")
+	runner, stateManager := newTestRunner(t, fake, Options{DryRun: true})
+
+	toolConfig := config.ToolConfig{Source: "homebrew", Tap: "owner/tools", Enabled: true}
+	if err := runner.InstallTool(context.Background(), "jq", toolConfig); err != nil {
+		t.Fatalf("InstallTool: %v", err)
+	}
+
+	for _, call := range fake.Calls() {
+		if call != brewInfo && call != "brew tap" {
+			t.Errorf("dry run ran %q", call)
+		}
+	}
+
+	recorder, ok := runner.Executor().(*executor.Recorder)
+	if !ok {
+		t.Fatalf("dry run executor is %T, want *executor.Recorder", runner.Executor())
+	}
+	var recorded []string
+	for _, cmd := range recorder.Commands() {
+		recorded = append(recorded, cmd.String())
+	}
+	want := []string{"brew tap owner/tools", "brew install owner/tools/jq"}
+	for _, line := range want {
+		found := false
+		for _, got := range recorded {
+			found = found || got == line
+		}
+		if !found {
+			t.Errorf("recorded commands %q lack %q", recorded, line)
+		}
+	}
+	if _, ok := stateManager.GetToolStatus("jq"); ok {
+		t.Error("dry run recorded the tool in state")
+	}
+}
@@ -0,0 +1,120 @@
+package installer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lukeberry99/devtool/internal/config"
+)
+
+type installSummary struct {
+	succeeded int
+	failed    int
+	skipped   int
+	// interrupted were running and notStarted still waiting when the run
+	// was cancelled.
+	interrupted []string
+	notStarted  []string
+}
+
+type installOutcome struct {
+	name string
+	err  error
+}
+
+// installInOrder installs tools using up to r.jobs workers. A tool is started
+// only once every dependency within the set has finished, and is skipped when
+// any of them did not install. With a single job this degrades to installing
+// strictly in the given order. Atomic runs stop starting tools after the first
+// failure, since everything will be rolled back anyway. Once ctx is cancelled
+// no further tools are started and the running ones are waited for.
+func (r *ToolRunner) installInOrder(ctx context.Context, order []string, tools map[string]config.ToolConfig) installSummary {
+	var summary installSummary
+
+	finished := make(map[string]bool, len(order))
+	unavailable := make(map[string]string) // tool -> why it is not installed
+	pending := append([]string{}, order...)
+	results := make(chan installOutcome)
+	running := 0
+	aborted := false
+
+	ready := func(name string) bool {
+		for _, dep := range tools[name].Dependencies {
+			if _, managed := tools[dep]; managed && !finished[dep] {
+				return false
+			}
+		}
+		return true
+	}
+
+	for len(pending) > 0 || running > 0 {
+		if ctx.Err() != nil {
+			summary.notStarted = append(summary.notStarted, pending...)
+			pending = nil
+		}
+
+		// Start or skip everything that is unblocked, preserving order
+		for progressed := true; progressed; {
+			progressed = false
+			remaining := pending[:0]
+			for _, name := range pending {
+				if !ready(name) {
+					remaining = append(remaining, name)
+					continue
+				}
+
+				if aborted {
+					r.logger.Warn(fmt.Sprintf("Skipping %s: an earlier tool failed and the run will be rolled back", name))
+					finished[name] = true
+					summary.skipped++
+					progressed = true
+					continue
+				}
+
+				if dep, blocked := blockedBy(tools[name], unavailable); blocked {
+					r.logger.Warn(fmt.Sprintf("Skipping %s: dependency %s %s", name, dep, unavailable[dep]))
+					unavailable[name] = "was skipped"
+					finished[name] = true
+					summary.skipped++
+					progressed = true
+					continue
+				}
+
+				if running == r.jobs {
+					remaining = append(remaining, name)
+					continue
+				}
+
+				running++
+				go func(name string) {
+					results <- installOutcome{name: name, err: r.forTool(name).InstallTool(ctx, name, tools[name])}
+				}(name)
+			}
+			pending = remaining
+		}
+
+		if running == 0 {
+			break
+		}
+
+		outcome := <-results
+		running--
+		finished[outcome.name] = true
+		if outcome.err != nil && ctx.Err() != nil {
+			r.logger.Warn(fmt.Sprintf("Interrupted while installing %s", outcome.name))
+			unavailable[outcome.name] = "was interrupted"
+			summary.interrupted = append(summary.interrupted, outcome.name)
+			continue
+		}
+		if outcome.err != nil {
+			r.logger.Error(fmt.Sprintf("Failed to install %s: %v", outcome.name, outcome.err))
+			unavailable[outcome.name] = "failed to install"
+			summary.failed++
+			aborted = r.tx != nil
+			continue
+		}
+		summary.succeeded++
+	}
+
+	return summary
+}
@@ -0,0 +1,119 @@
+package installer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/lukeberry99/devtool/internal/config"
+	"github.com/lukeberry99/devtool/internal/executor"
+)
+
+func (r *ToolRunner) runCustomScript(ctx context.Context, name string, toolConfig config.ToolConfig) error {
+	script := toolConfig.ScriptConfig
+	if script == nil {
+		return fmt.Errorf("script configuration is required for script installs")
+	}
+	if (script.Run == "") == (script.File == "") {
+		return fmt.Errorf("script configuration must set exactly one of run or file")
+	}
+
+	r.logger.Info(fmt.Sprintf("Running custom script for %s", name))
+
+	// 1. Let the check script decide whether there is anything to do. It is
+	// user shell, so dry runs record it rather than run it and carry on as if
+	// it failed.
+	if script.Check != "" {
+		check := r.scriptCommand(name, toolConfig, script.Check, "")
+		check.Interactive = false
+		err := runStep(ctx, r.executor, check)
+		switch {
+		case r.dryRun:
+			r.logger.Debug(fmt.Sprintf("Check script for %s is not run in a dry run", name))
+		case err == nil:
+			r.logger.Success(fmt.Sprintf("%s check passed, skipping script", name))
+			r.updateToolState(ctx, name, toolConfig, "script")
+			return nil
+		default:
+			r.logger.Debug(fmt.Sprintf("Check script for %s did not pass: %v", name, err))
+		}
+	}
+
+	// 2. Run the install script
+	stdout, stderr := r.logger.Writer(), r.logger.ErrorWriter()
+	defer stdout.Close()
+	defer stderr.Close()
+
+	cmd := r.scriptCommand(name, toolConfig, script.Run, script.File)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	r.logger.Debug(fmt.Sprintf("Executing %s", cmd))
+	if err := runStep(ctx, r.executor, cmd); err != nil {
+		return fmt.Errorf("script for %s failed: %w", name, err)
+	}
+
+	// Scripts can only be undone through their uninstall script
+	if script.Uninstall != "" {
+		r.recordUndo(name, "run uninstall script", func(ctx context.Context) error {
+			return r.uninstallScript(ctx, name, toolConfig)
+		})
+	} else {
+		r.recordUndo(name, "undo install script", func(context.Context) error {
+			return fmt.Errorf("no uninstall script configured; remove %s manually", name)
+		})
+	}
+
+	// 3. Only record the tool once the script has succeeded
+	r.updateToolState(ctx, name, toolConfig, "script")
+
+	r.logger.Success(fmt.Sprintf("%s installed successfully", name))
+	return nil
+}
+
+// scriptCommand builds the command for an inline script or a script file.
+// Inline scripts are passed to the interpreter with -c; files are passed as the
+// first argument, followed by the configured args. Vendor installers often
+// prompt, so scripts keep the terminal.
+func (r *ToolRunner) scriptCommand(name string, toolConfig config.ToolConfig, inline, file string) executor.Command {
+	script := toolConfig.ScriptConfig
+
+	interpreter := strings.Fields(script.Interpreter)
+	if len(interpreter) == 0 {
+		interpreter = []string{"sh"}
+	}
+
+	var args []string
+	if file != "" {
+		args = append(args, interpreter[1:]...)
+		args = append(args, resolvePath(r.configDir, file))
+		args = append(args, script.Args...)
+	} else {
+		args = append(args, interpreter[1:]...)
+		args = append(args, "-c", inline)
+	}
+
+	cmd := executor.New(interpreter[0], args...)
+	cmd.Interactive = true
+	cmd.Dir = r.configDir
+	if script.WorkDir != "" {
+		cmd.Dir = resolvePath(r.configDir, script.WorkDir)
+	}
+
+	cmd.Env = append(cmd.Env,
+		"DEVTOOL_TOOL="+name,
+		"DEVTOOL_VERSION="+toolConfig.Version,
+		"DEVTOOL_CONFIG_DIR="+r.configDir,
+	)
+	keys := make([]string, 0, len(script.Env))
+	for key := range script.Env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", key, os.ExpandEnv(script.Env[key])))
+	}
+
+	return cmd
+}
@@ -0,0 +1,150 @@
+package installer
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/lukeberry99/devtool/internal/config"
+)
+
+// Selection narrows the configured tools down to the ones a command acts on.
+// Empty fields do not filter; set fields must all match.
+type Selection struct {
+	Pattern string
+	Tools   []string
+	Profile string
+}
+
+func (s Selection) empty() bool {
+	return s.Pattern == "" && len(s.Tools) == 0 && s.Profile == ""
+}
+
+func (s Selection) String() string {
+	var parts []string
+	if s.Pattern != "" {
+		parts = append(parts, fmt.Sprintf("pattern %q", s.Pattern))
+	}
+	if len(s.Tools) > 0 {
+		parts = append(parts, fmt.Sprintf("tools %s", strings.Join(s.Tools, ",")))
+	}
+	if s.Profile != "" {
+		parts = append(parts, fmt.Sprintf("profile %q", s.Profile))
+	}
+	return strings.Join(parts, " and ")
+}
+
+// NameMatcher compiles a tool name pattern. Patterns containing glob
+// metacharacters (*, ? or [) are matched as globs against the whole name;
+// anything else is a regular expression that may match part of the name.
+func NameMatcher(pattern string) (func(name string) bool, error) {
+	if strings.ContainsAny(pattern, "*?[") {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid glob pattern %q: %w", pattern, err)
+		}
+		return func(name string) bool {
+			matched, _ := path.Match(pattern, name)
+			return matched
+		}, nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	return re.MatchString, nil
+}
+
+// SelectTools returns the enabled tools picked by the selection together with
+// everything they depend on. It fails when the selection matches nothing.
+func SelectTools(cfg *config.Config, selection Selection) (map[string]config.ToolConfig, error) {
+	if selection.empty() {
+		return cfg.Tools, nil
+	}
+
+	var filters []func(name string, toolConfig config.ToolConfig) bool
+
+	if selection.Pattern != "" {
+		match, err := NameMatcher(selection.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, func(name string, _ config.ToolConfig) bool {
+			return match(name)
+		})
+	}
+
+	if len(selection.Tools) > 0 {
+		wanted := make(map[string]bool, len(selection.Tools))
+		for _, name := range selection.Tools {
+			if _, ok := cfg.Tools[name]; !ok {
+				return nil, fmt.Errorf("tool %q is not in the configuration", name)
+			}
+			wanted[name] = true
+		}
+		filters = append(filters, func(name string, _ config.ToolConfig) bool {
+			return wanted[name]
+		})
+	}
+
+	if selection.Profile != "" {
+		profile, err := cfg.ResolveProfile(selection.Profile)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, func(name string, _ config.ToolConfig) bool {
+			_, ok := profile.Tools[name]
+			return ok
+		})
+	}
+
+	selected := make(map[string]config.ToolConfig)
+	for name, toolConfig := range cfg.Tools {
+		if !toolConfig.Enabled {
+			continue
+		}
+		matched := true
+		for _, filter := range filters {
+			if !filter(name, toolConfig) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			selected[name] = toolConfig
+		}
+	}
+
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no enabled tools match %s", selection)
+	}
+
+	// Pull in dependencies so the selected tools can actually install
+	var addDependencies func(name string)
+	addDependencies = func(name string) {
+		for _, dep := range cfg.Tools[name].Dependencies {
+			if _, done := selected[dep]; done {
+				continue
+			}
+			if depConfig, ok := cfg.Tools[dep]; ok {
+				selected[dep] = depConfig
+				addDependencies(dep)
+			}
+		}
+	}
+	for _, name := range sortedToolNames(selected) {
+		addDependencies(name)
+	}
+
+	return selected, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
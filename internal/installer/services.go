@@ -0,0 +1,192 @@
+package installer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+
+	"github.com/lukeberry99/devtool/internal/config"
+)
+
+// BrewService is an entry of brew services list --json.
+type BrewService struct {
+	Name   string `json:"name"`
+	Status string `json:"status"` // "started", "scheduled", "stopped", "error", "none", ...
+	User   string `json:"user"`
+}
+
+// Registered reports whether brew services manages the service, running or
+// not; registered services start at login.
+func (s BrewService) Registered() bool {
+	return s.Status != "" && s.Status != "none"
+}
+
+func (s BrewService) Running() bool {
+	return s.Status == "started" || s.Status == "scheduled"
+}
+
+// Services returns the services brew knows about, keyed by formula name.
+func (h *HomebrewManager) Services(ctx context.Context) (map[string]BrewService, error) {
+	output, err := h.query(ctx, "services", "list", "--json")
+	if err != nil {
+		return nil, fmt.Errorf("brew services list failed: %w", err)
+	}
+
+	var list []BrewService
+	if err := json.Unmarshal(output, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse brew services output: %w", err)
+	}
+
+	services := make(map[string]BrewService, len(list))
+	for _, service := range list {
+		services[service.Name] = service
+	}
+	return services, nil
+}
+
+// StartService starts a service now and registers it to start at login.
+func (h *HomebrewManager) StartService(ctx context.Context, formula string) error {
+	return h.services(ctx, "start", formula)
+}
+
+// StopService stops a service and unregisters it.
+func (h *HomebrewManager) StopService(ctx context.Context, formula string) error {
+	return h.services(ctx, "stop", formula)
+}
+
+func (h *HomebrewManager) RestartService(ctx context.Context, formula string) error {
+	return h.services(ctx, "restart", formula)
+}
+
+func (h *HomebrewManager) services(ctx context.Context, verb, formula string) error {
+	h.lane.Lock()
+	defer h.lane.Unlock()
+
+	cmd := h.command("services", verb, formula)
+	stdout, stderr := h.logger.Writer(), h.logger.ErrorWriter()
+	defer stdout.Close()
+	defer stderr.Close()
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	if err := h.executor.Run(ctx, cmd); err != nil {
+		return fmt.Errorf("brew services %s %s failed: %w", verb, formula, err)
+	}
+	return nil
+}
+
+// syncService brings the brew service of a tool in line with its service
+// block: started when it should run at login, stopped otherwise, and
+// restarted after an upgrade when asked to.
+func (r *ToolRunner) syncService(ctx context.Context, name string, toolConfig config.ToolConfig, upgraded bool) error {
+	service := toolConfig.Service
+	if service == nil {
+		return nil
+	}
+	if toolConfig.Source != "homebrew" || toolConfig.Cask {
+		return fmt.Errorf("%s has a service, but services are only supported for Homebrew formulae", name)
+	}
+
+	services, err := r.homebrew.Services(ctx)
+	if err != nil {
+		return err
+	}
+	formula := homebrewName(name, toolConfig)
+	current := services[path.Base(formula)]
+
+	switch {
+	case service.RunAtLogin && current.Status == "error":
+		r.logger.Step(fmt.Sprintf("Restarting %s service, which failed", name))
+		return r.homebrew.RestartService(ctx, formula)
+	case service.RunAtLogin && !current.Running():
+		r.logger.Step(fmt.Sprintf("Starting %s service", name))
+		if err := r.homebrew.StartService(ctx, formula); err != nil {
+			return err
+		}
+		r.recordUndo(name, fmt.Sprintf("brew services stop %s", formula), func(ctx context.Context) error {
+			return r.homebrew.StopService(ctx, formula)
+		})
+	case service.RunAtLogin && upgraded && service.RestartOnUpgrade:
+		r.logger.Step(fmt.Sprintf("Restarting %s service after the upgrade", name))
+		return r.homebrew.RestartService(ctx, formula)
+	case !service.RunAtLogin && current.Registered():
+		r.logger.Step(fmt.Sprintf("Stopping %s service", name))
+		return r.homebrew.StopService(ctx, formula)
+	default:
+		r.logger.Debug(fmt.Sprintf("%s service is %s", name, current.Status))
+	}
+	return nil
+}
+
+// syncCurrentService keeps the service of a tool that needs no install in
+// line with its configuration. Plans list it as an action of its own.
+func (r *ToolRunner) syncCurrentService(ctx context.Context, name string, toolConfig config.ToolConfig) error {
+	if toolConfig.Service == nil {
+		return nil
+	}
+	if r.planner != nil {
+		action := PlanAction{Tool: name, Source: toolConfig.Source, Reason: ReasonService}
+		return r.planner.record(action, func() error {
+			return r.syncService(ctx, name, toolConfig, false)
+		})
+	}
+	return r.syncService(ctx, name, toolConfig, false)
+}
+
+// stopService stops the service of a formula about to be uninstalled, if
+// brew services manages it.
+func (r *ToolRunner) stopService(ctx context.Context, formula string) error {
+	services, err := r.homebrew.Services(ctx)
+	if err != nil {
+		r.logger.Debug(fmt.Sprintf("Could not list services: %v", err))
+		return nil
+	}
+	if !services[path.Base(formula)].Registered() {
+		return nil
+	}
+
+	r.logger.Step(fmt.Sprintf("Stopping %s service", formula))
+	return r.homebrew.StopService(ctx, formula)
+}
+
+// ServiceReport describes the service of a configured tool.
+type ServiceReport struct {
+	Name   string
+	Wanted string // "running" or "stopped"
+	Actual string // as reported by brew services, "none" when unknown to it
+	Status string // "ok" or "mismatch"
+}
+
+// ServiceReports compares the services of enabled tools with brew services.
+func (r *ToolRunner) ServiceReports(ctx context.Context, tools map[string]config.ToolConfig) ([]ServiceReport, error) {
+	var reports []ServiceReport
+	var services map[string]BrewService
+	for _, name := range sortedToolNames(tools) {
+		toolConfig := tools[name]
+		if !toolConfig.Enabled || toolConfig.Service == nil {
+			continue
+		}
+
+		if services == nil {
+			var err error
+			if services, err = r.homebrew.Services(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		service := services[path.Base(homebrewName(name, toolConfig))]
+		report := ServiceReport{Name: name, Wanted: "stopped", Actual: service.Status, Status: "ok"}
+		if report.Actual == "" {
+			report.Actual = "none"
+		}
+		if toolConfig.Service.RunAtLogin {
+			report.Wanted = "running"
+		}
+		if toolConfig.Service.RunAtLogin != service.Running() || (!toolConfig.Service.RunAtLogin && service.Registered()) {
+			report.Status = "mismatch"
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
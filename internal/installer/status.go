@@ -0,0 +1,53 @@
+package installer
+
+import (
+	"github.com/lukeberry99/devtool/internal/config"
+	"github.com/lukeberry99/devtool/internal/version"
+)
+
+// ToolReport describes a configured tool as recorded in state.
+type ToolReport struct {
+	Name      string
+	Source    string
+	Installed string
+	Wanted    string
+	Status    string // "ok", "missing" or "mismatch"
+}
+
+// ToolReports compares every enabled tool against state with the checks
+// install makes. It only reads state, so it is cheap enough to run at any
+// time; tools that follow a channel are reported as installed, not checked
+// upstream.
+func (r *ToolRunner) ToolReports(tools map[string]config.ToolConfig) []ToolReport {
+	installed := r.stateManager.GetAllTools()
+
+	reports := make([]ToolReport, 0, len(tools))
+	for _, name := range sortedToolNames(tools) {
+		toolConfig := tools[name]
+		if !toolConfig.Enabled {
+			continue
+		}
+
+		report := ToolReport{
+			Name:   name,
+			Source: toolConfig.Source,
+			Wanted: toolConfig.Version,
+			Status: "missing",
+		}
+		if report.Wanted == "" {
+			report.Wanted = "any"
+		}
+
+		if status, ok := installed[name]; ok && status.Installed {
+			report.Source = status.Source
+			report.Installed = version.Normalize(status.Version)
+			report.Status = "ok"
+			if current, err := recordedCurrent(status, toolConfig); err != nil || !current {
+				report.Status = "mismatch"
+			}
+		}
+
+		reports = append(reports, report)
+	}
+	return reports
+}
@@ -0,0 +1,123 @@
+package installer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/lukeberry99/devtool/internal/config"
+)
+
+// homebrewName is the name brew knows a tool by, qualified with its tap when
+// it comes from one.
+func homebrewName(name string, toolConfig config.ToolConfig) string {
+	if toolConfig.Tap != "" {
+		return toolConfig.Tap + "/" + name
+	}
+	return name
+}
+
+// ensureTaps taps the configured taps and extra, skipping those already
+// present.
+func (r *ToolRunner) ensureTaps(ctx context.Context, extra []string) error {
+	var taps []string
+	for _, tap := range r.brewConfig.Taps {
+		taps = append(taps, tap.Name)
+	}
+	taps = append(taps, extra...)
+
+	seen := make(map[string]bool)
+	for _, tap := range taps {
+		if seen[strings.ToLower(tap)] {
+			continue
+		}
+		seen[strings.ToLower(tap)] = true
+		if err := r.ensureTap(ctx, tap); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ensureTap taps tap unless it is present and remembers taps devtool added,
+// so UntapUnused never removes a tap the user added.
+func (r *ToolRunner) ensureTap(ctx context.Context, tap string) error {
+	added, err := r.homebrew.Tap(ctx, tap)
+	if err != nil {
+		return err
+	}
+	if !added {
+		return nil
+	}
+	r.recordUndo("homebrew", fmt.Sprintf("brew untap %s", tap), func(ctx context.Context) error {
+		if err := r.homebrew.Untap(ctx, tap); err != nil {
+			return err
+		}
+		if r.stateManager == nil {
+			return nil
+		}
+		r.stateManager.RemoveTap(tap)
+		return r.stateManager.Save()
+	})
+	if r.stateManager == nil || r.dryRun {
+		return nil
+	}
+
+	r.stateManager.AddTap(tap)
+	if err := r.stateManager.Save(); err != nil {
+		r.logger.Warn(fmt.Sprintf("Failed to save state: %v", err))
+	}
+	return nil
+}
+
+// UntapUnused removes the taps devtool added that are neither configured in
+// homebrew.taps, nor the tap of an enabled tool in tools, nor the tap of a
+// tool that is still installed.
+func (r *ToolRunner) UntapUnused(ctx context.Context, tools map[string]config.ToolConfig) error {
+	if r.stateManager == nil {
+		return fmt.Errorf("state is required to untap unused taps")
+	}
+
+	used := make(map[string]bool)
+	for _, tap := range r.brewConfig.Taps {
+		used[strings.ToLower(tap.Name)] = true
+	}
+	for _, toolConfig := range tools {
+		if toolConfig.Enabled && toolConfig.Source == "homebrew" && toolConfig.Tap != "" {
+			used[strings.ToLower(toolConfig.Tap)] = true
+		}
+	}
+	for _, status := range r.stateManager.GetAllTools() {
+		if status.Installed && status.Tap != "" {
+			used[strings.ToLower(status.Tap)] = true
+		}
+	}
+
+	var failed []string
+	for _, tap := range r.stateManager.Taps() {
+		if used[strings.ToLower(tap)] {
+			continue
+		}
+
+		r.logger.Step(fmt.Sprintf("Untapping %s, which nothing uses anymore", tap))
+		if err := r.homebrew.Untap(ctx, tap); err != nil {
+			r.logger.Warn(err.Error())
+			failed = append(failed, tap)
+			continue
+		}
+		if !r.dryRun {
+			r.stateManager.RemoveTap(tap)
+		}
+	}
+
+	if !r.dryRun {
+		if err := r.stateManager.Save(); err != nil {
+			r.logger.Warn(fmt.Sprintf("Failed to save state: %v", err))
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to untap %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+package installer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/lukeberry99/devtool/internal/state"
+	"github.com/lukeberry99/devtool/internal/ui"
+)
+
+// undoStep reverts one completed change of a transactional run.
+type undoStep struct {
+	tool        string
+	description string
+	undo        func(ctx context.Context) error
+}
+
+// transaction collects undo steps while tools install, so an atomic run can
+// put the machine back the way it found it. Steps are recorded as changes
+// complete and unwound in reverse order.
+type transaction struct {
+	mu        sync.Mutex
+	steps     []undoStep
+	backupDir string
+}
+
+func newTransaction() *transaction {
+	return &transaction{}
+}
+
+func (t *transaction) record(tool, description string, undo func(ctx context.Context) error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.steps = append(t.steps, undoStep{tool: tool, description: description, undo: undo})
+}
+
+// backup copies an existing file aside and records restoring it. Paths that do
+// not exist yet are recorded for removal instead.
+func (t *transaction) backup(tool, path string) error {
+	if _, err := os.Lstat(path); os.IsNotExist(err) {
+		t.record(tool, fmt.Sprintf("remove %s", path), func(context.Context) error {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			return nil
+		})
+		return nil
+	}
+
+	t.mu.Lock()
+	if t.backupDir == "" {
+		dir, err := os.MkdirTemp("", "devtool-undo-")
+		if err != nil {
+			t.mu.Unlock()
+			return fmt.Errorf("failed to create backup directory: %w", err)
+		}
+		t.backupDir = dir
+	}
+	copyPath := filepath.Join(t.backupDir, fmt.Sprintf("%d-%s", len(t.steps), filepath.Base(path)))
+	t.mu.Unlock()
+
+	if err := copyFile(path, copyPath); err != nil {
+		return fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+	t.record(tool, fmt.Sprintf("restore previous %s", path), func(context.Context) error {
+		return copyExecutable(copyPath, path)
+	})
+	return nil
+}
+
+// rollback runs every recorded step, newest first, reporting each one. It
+// returns how many steps could not be undone.
+func (t *transaction) rollback(ctx context.Context, logger *ui.Logger) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.steps) == 0 {
+		logger.Info("Nothing to roll back")
+		return 0
+	}
+
+	logger.Section(fmt.Sprintf("↩️  Rolling back %d changes", len(t.steps)))
+
+	failed := 0
+	for i := len(t.steps) - 1; i >= 0; i-- {
+		step := t.steps[i]
+		logger.Step(fmt.Sprintf("[%s] Undo: %s", step.tool, step.description))
+		if err := step.undo(ctx); err != nil {
+			logger.Error(fmt.Sprintf("[%s] Failed to %s: %v", step.tool, step.description, err))
+			failed++
+			continue
+		}
+	}
+	t.steps = nil
+
+	if t.backupDir != "" {
+		os.RemoveAll(t.backupDir)
+		t.backupDir = ""
+	}
+
+	if failed > 0 {
+		logger.Warn(fmt.Sprintf("Rollback finished with %d steps that could not be undone", failed))
+	} else {
+		logger.Success("Rollback complete")
+	}
+	return failed
+}
+
+// discard forgets the recorded steps once a run has succeeded.
+func (t *transaction) discard() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.steps = nil
+	if t.backupDir != "" {
+		os.RemoveAll(t.backupDir)
+		t.backupDir = ""
+	}
+}
+
+// abort rolls back an atomic run that failed before its tools were installed
+// and returns err, saying whether the rollback was complete.
+func (r *ToolRunner) abort(err error) error {
+	if r.tx == nil || r.dryRun {
+		return err
+	}
+	if failed := r.tx.rollback(context.Background(), r.logger); failed > 0 {
+		return fmt.Errorf("%w; %d changes could not be rolled back", err, failed)
+	}
+	return fmt.Errorf("%w; all changes were rolled back", err)
+}
+
+// recordUndo adds an undo step when the run is transactional.
+func (r *ToolRunner) recordUndo(tool, description string, undo func(ctx context.Context) error) {
+	if r.tx == nil || r.dryRun {
+		return
+	}
+	r.tx.record(tool, description, undo)
+}
+
+// recordStateUndo remembers a tool's state entry before it is overwritten, so
+// rolling back restores the previous entry or removes a new one.
+func (r *ToolRunner) recordStateUndo(name string) {
+	if r.tx == nil || r.dryRun || r.stateManager == nil {
+		return
+	}
+
+	previous, existed := r.stateManager.GetToolStatus(name)
+	r.tx.record(name, "revert state.json entry", func(context.Context) error {
+		if existed {
+			r.stateManager.UpdateToolStatus(name, previous)
+		} else {
+			r.stateManager.RemoveToolStatus(name)
+		}
+		return r.stateManager.Save()
+	})
+}
+
+// recordPrefixUndo records switching a source build back to the build that was
+// active before, or removing the new build when there was none. Fresh builds
+// are deleted after switching back; retained ones are kept.
+func (r *ToolRunner) recordPrefixUndo(name, prefix, binDir string, previous state.ToolStatus, links []string, fresh bool) {
+	if r.tx == nil || r.dryRun {
+		return
+	}
+
+	if previous.Prefix != "" && previous.Prefix != prefix {
+		r.tx.record(name, fmt.Sprintf("switch back to build %s", filepath.Base(previous.Prefix)), func(context.Context) error {
+			if !prefixIntact(previous.Prefix) {
+				return fmt.Errorf("previous build %s is missing", previous.Prefix)
+			}
+			if _, err := r.activatePrefix(name, previous.Prefix, binDir, links); err != nil {
+				return err
+			}
+			if fresh {
+				return os.RemoveAll(prefix)
+			}
+			return nil
+		})
+		return
+	}
+
+	r.tx.record(name, fmt.Sprintf("remove build %s", filepath.Base(prefix)), func(context.Context) error {
+		for _, link := range links {
+			if r.ownsLink(name, link) {
+				os.Remove(link)
+			}
+		}
+		os.Remove(currentLink(name))
+		if err := os.RemoveAll(prefix); err != nil {
+			return err
+		}
+		os.Remove(optDir(name)) // only succeeds when no other build is left
+		return nil
+	})
+}
+
+func copyFile(source, target string) error {
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,237 @@
+package installer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/lukeberry99/devtool/internal/config"
+	"github.com/lukeberry99/devtool/internal/executor"
+	"github.com/lukeberry99/devtool/internal/lockfile"
+	"github.com/lukeberry99/devtool/internal/state"
+)
+
+// UninstallTools removes each named tool and its state entry. Tools missing
+// from the configuration are still removed using what state recorded.
+func (r *ToolRunner) UninstallTools(ctx context.Context, names []string, tools map[string]config.ToolConfig) error {
+	failed := 0
+	for _, name := range names {
+		if err := r.UninstallTool(ctx, name, tools[name]); err != nil {
+			r.logger.Error(fmt.Sprintf("Failed to uninstall %s: %v", name, err))
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d tools failed to uninstall", failed, len(names))
+	}
+	return nil
+}
+
+// UninstallTool removes a tool according to the source recorded in state.
+func (r *ToolRunner) UninstallTool(ctx context.Context, name string, toolConfig config.ToolConfig) error {
+	r.logger.Section(fmt.Sprintf("Uninstalling %s", name))
+
+	if r.stateManager == nil {
+		return fmt.Errorf("state is required to uninstall tools")
+	}
+
+	status, exists := r.stateManager.GetToolStatus(name)
+	if !exists || !status.Installed {
+		return fmt.Errorf("%s is not installed by devtool", name)
+	}
+
+	var err error
+	switch status.Source {
+	case "homebrew":
+		// The tap and cask are recorded in case the tool has left or changed
+		// in the configuration
+		if toolConfig.Tap == "" {
+			toolConfig.Tap = status.Tap
+		}
+		toolConfig.Cask = toolConfig.Cask || status.Cask
+		err = r.uninstallFromHomebrew(ctx, name, toolConfig)
+	case "system":
+		err = r.uninstallFromSystem(ctx, name, toolConfig)
+	case "built_from_source":
+		err = r.uninstallBuild(ctx, name, toolConfig, status)
+	case "script":
+		err = r.uninstallScript(ctx, name, toolConfig)
+	case "download", "release":
+		err = r.removeFiles(ctx, name, status.Files)
+	case "go", "cargo", "pipx", "npm":
+		err = r.uninstallFromEcosystem(ctx, name, toolConfig, status)
+	default:
+		err = fmt.Errorf("don't know how to uninstall tools from source %q", status.Source)
+	}
+	if err != nil {
+		return err
+	}
+
+	if r.dryRun {
+		r.logger.Info(fmt.Sprintf("[DRY RUN] Would remove %s from state and %s", name, lockfile.FileName))
+		return nil
+	}
+
+	r.stateManager.RemoveToolStatus(name)
+	if err := r.stateManager.Save(); err != nil {
+		r.logger.Warn(fmt.Sprintf("Failed to save state: %v", err))
+	}
+	if err := r.unlockTool(name); err != nil {
+		r.logger.Warn(fmt.Sprintf("Failed to update %s: %v", lockfile.FileName, err))
+	}
+
+	r.logger.Success(fmt.Sprintf("%s uninstalled", name))
+	return nil
+}
+
+func (r *ToolRunner) uninstallFromHomebrew(ctx context.Context, name string, toolConfig config.ToolConfig) error {
+	formula := homebrewName(name, toolConfig)
+	if toolConfig.Cask || contains(toolConfig.HomebrewArgs, "--cask") {
+		return r.homebrew.UninstallCask(ctx, formula)
+	}
+	if err := r.stopService(ctx, formula); err != nil {
+		return err
+	}
+	return r.homebrew.UninstallPackages(ctx, []string{formula})
+}
+
+func (r *ToolRunner) uninstallFromSystem(ctx context.Context, name string, toolConfig config.ToolConfig) error {
+	if r.system == nil {
+		return r.systemErr
+	}
+	return r.system.UninstallPackages(ctx, systemPackages(name, toolConfig, r.system))
+}
+
+// uninstallBuild removes devtool-managed prefixes; builds installed elsewhere
+// run the configured uninstall steps or delete the files the build system
+// reported installing.
+func (r *ToolRunner) uninstallBuild(ctx context.Context, name string, toolConfig config.ToolConfig, status state.ToolStatus) error {
+	// Prefixed builds are removed with their links and every retained build
+	if status.Prefix != "" {
+		if err := r.removeFiles(ctx, name, status.Files); err != nil {
+			return err
+		}
+		if r.dryRun {
+			r.logger.Info(fmt.Sprintf("[DRY RUN] Would remove %s", optDir(name)))
+			return nil
+		}
+		r.logger.Step(fmt.Sprintf("Removing %s", optDir(name)))
+		if err := os.RemoveAll(optDir(name)); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", optDir(name), err)
+		}
+		return nil
+	}
+
+	if toolConfig.BuildConfig != nil && len(toolConfig.BuildConfig.UninstallSteps) > 0 {
+		steps := toolConfig.BuildConfig.UninstallSteps
+		workspace := newBuildWorkspace(name, r.logger, r.executor, r.retry)
+		if !workspace.exists() {
+			return fmt.Errorf("build directory %s is missing; uninstall steps need it", workspace.dir)
+		}
+
+		unlock := workspace.lock()
+		defer unlock()
+		if err := workspace.runSteps(ctx, "uninstall", steps, true); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	if len(status.Files) == 0 {
+		return fmt.Errorf("no uninstall_steps configured and no installed files recorded for %s", name)
+	}
+	return r.removeFiles(ctx, name, status.Files)
+}
+
+func (r *ToolRunner) uninstallScript(ctx context.Context, name string, toolConfig config.ToolConfig) error {
+	if toolConfig.ScriptConfig == nil || toolConfig.ScriptConfig.Uninstall == "" {
+		return fmt.Errorf("no uninstall script configured for %s", name)
+	}
+
+	stdout, stderr := r.logger.Writer(), r.logger.ErrorWriter()
+	defer stdout.Close()
+	defer stderr.Close()
+
+	cmd := r.scriptCommand(name, toolConfig, toolConfig.ScriptConfig.Uninstall, "")
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := runStep(ctx, r.executor, cmd); err != nil {
+		return fmt.Errorf("uninstall script for %s failed: %w", name, err)
+	}
+	return nil
+}
+
+func (r *ToolRunner) uninstallFromEcosystem(ctx context.Context, name string, toolConfig config.ToolConfig, status state.ToolStatus) error {
+	toolConfig.Source = status.Source
+	eco := ecosystems[status.Source]
+
+	if eco.uninstallArgs == nil {
+		binaryPath := status.BinaryPath
+		if binaryPath == "" {
+			var err error
+			if binaryPath, err = goBinaryPath(ctx, r.executor, ecosystemBinary(name, toolConfig)); err != nil {
+				return err
+			}
+		}
+		return r.removeFiles(ctx, name, []string{binaryPath})
+	}
+
+	args := eco.uninstallArgs(ecosystemPackage(name, toolConfig))
+	eco.lane.Lock()
+	defer eco.lane.Unlock()
+
+	stdout, stderr := r.logger.Writer(), r.logger.ErrorWriter()
+	defer stdout.Close()
+	defer stderr.Close()
+
+	cmd := executor.New(eco.binary, args...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := runStep(ctx, r.executor, cmd); err != nil {
+		return fmt.Errorf("%s %s failed: %w", eco.binary, strings.Join(args, " "), err)
+	}
+	return nil
+}
+
+// removeFiles deletes recorded files. Files devtool cannot remove itself (for
+// example those installed with sudo) are removed through sudo.
+func (r *ToolRunner) removeFiles(ctx context.Context, name string, files []string) error {
+	if len(files) == 0 {
+		r.logger.Warn(fmt.Sprintf("No installed files recorded for %s", name))
+		return nil
+	}
+
+	var privileged []string
+	for _, file := range files {
+		if r.dryRun {
+			r.logger.Info(fmt.Sprintf("[DRY RUN] Would remove %s", file))
+			continue
+		}
+
+		r.logger.Step(fmt.Sprintf("Removing %s", file))
+		err := os.Remove(file)
+		switch {
+		case err == nil, errors.Is(err, fs.ErrNotExist):
+		case errors.Is(err, fs.ErrPermission):
+			privileged = append(privileged, file)
+		default:
+			return fmt.Errorf("failed to remove %s: %w", file, err)
+		}
+	}
+
+	if len(privileged) == 0 {
+		return nil
+	}
+
+	cmd := executor.New("sudo", append([]string{"rm", "-f", "--"}, privileged...)...)
+	cmd.Interactive = true
+	cmd.Stderr = os.Stderr
+	if err := r.executor.Run(ctx, cmd); err != nil {
+		return fmt.Errorf("failed to remove files with sudo: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package installer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lukeberry99/devtool/internal/config"
+	"github.com/lukeberry99/devtool/internal/executor"
+	"github.com/lukeberry99/devtool/internal/lockfile"
+	"github.com/lukeberry99/devtool/internal/state"
+)
+
+func TestUninstallRecordedCask(t *testing.T) {
+	fake := executor.NewFake().
+		Path("brew", "/opt/homebrew/bin/brew").
+		On("brew uninstall --cask nikitabobko/tap/aerospace")
+	configDir := t.TempDir()
+	runner, stateManager := newTestRunner(t, fake, Options{ConfigDir: configDir})
+
+	lock := lockfile.New()
+	lock.Tools["aerospace"] = lockfile.Entry{Source: "homebrew", Formula: "nikitabobko/tap/aerospace"}
+	lock.Tools["jq"] = lockfile.Entry{Source: "homebrew", Formula: "jq"}
+	if err := lock.Save(lockfile.Path(configDir)); err != nil {
+		t.Fatal(err)
+	}
+	stateManager.UpdateToolStatus("aerospace", state.ToolStatus{Installed: true, Source: "homebrew", Tap: "nikitabobko/tap", Cask: true})
+
+	// The tool has left the configuration, so only state knows it is a cask
+	if err := runner.UninstallTool(context.Background(), "aerospace", config.ToolConfig{}); err != nil {
+		t.Fatalf("UninstallTool: %v\ncommands: %q", err, fake.Calls())
+	}
+
+	if _, ok := stateManager.GetToolStatus("aerospace"); ok {
+		t.Error("aerospace is still in state")
+	}
+	lock, err := lockfile.Load(lockfile.Path(configDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := lock.Tools["aerospace"]; ok {
+		t.Error("aerospace is still in the lockfile")
+	}
+	if _, ok := lock.Tools["jq"]; !ok {
+		t.Error("jq was dropped from the lockfile")
+	}
+}
+
+func TestUninstallWithoutLockfile(t *testing.T) {
+	fake := executor.NewFake().
+		Path("brew", "/opt/homebrew/bin/brew").
+		On("brew uninstall jq")
+	configDir := t.TempDir()
+	runner, stateManager := newTestRunner(t, fake, Options{ConfigDir: configDir})
+	stateManager.UpdateToolStatus("jq", state.ToolStatus{Installed: true, Source: "homebrew"})
+
+	if err := runner.UninstallTool(context.Background(), "jq", config.ToolConfig{Source: "homebrew"}); err != nil {
+		t.Fatalf("UninstallTool: %v\ncommands: %q", err, fake.Calls())
+	}
+	if _, err := os.Stat(filepath.Join(configDir, lockfile.FileName)); !os.IsNotExist(err) {
+		t.Errorf("uninstall created a lockfile: %v", err)
+	}
+}
@@ -0,0 +1,29 @@
+package installer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func expandPath(path string) string {
+	// Expand environment variables
+	path = os.ExpandEnv(path)
+
+	// Expand ~ to home directory
+	if strings.HasPrefix(path, "~/") {
+		homeDir, _ := os.UserHomeDir()
+		path = filepath.Join(homeDir, path[2:])
+	}
+
+	return path
+}
+
+// resolvePath expands path and makes it absolute relative to baseDir.
+func resolvePath(baseDir, path string) string {
+	path = expandPath(path)
+	if filepath.IsAbs(path) || baseDir == "" {
+		return path
+	}
+	return filepath.Join(baseDir, path)
+}
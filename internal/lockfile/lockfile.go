@@ -0,0 +1,93 @@
+// Package lockfile reads and writes devtool.lock, which records the exact
+// versions a configuration resolved to so other machines can reproduce them.
+package lockfile
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the lockfile name, written next to the configuration file.
+const FileName = "devtool.lock"
+
+// formatVersion is bumped when the lockfile layout changes incompatibly.
+const formatVersion = 1
+
+type Lockfile struct {
+	Version int              `yaml:"version"`
+	Tools   map[string]Entry `yaml:"tools"`
+}
+
+// Entry is the resolved install of one tool.
+type Entry struct {
+	Source   string `yaml:"source"`
+	Version  string `yaml:"version,omitempty"`
+	Formula  string `yaml:"formula,omitempty"`  // homebrew: name as installed
+	Tap      string `yaml:"tap,omitempty"`      // homebrew: tap the formula comes from
+	Commit   string `yaml:"commit,omitempty"`   // build: commit that was built
+	URL      string `yaml:"url,omitempty"`      // download, release: artifact URL
+	Checksum string `yaml:"checksum,omitempty"` // download, release: sha256 of the artifact
+}
+
+// Path returns the lockfile location for a configuration directory.
+func Path(configDir string) string {
+	return filepath.Join(configDir, FileName)
+}
+
+func New() *Lockfile {
+	return &Lockfile{
+		Version: formatVersion,
+		Tools:   make(map[string]Entry),
+	}
+}
+
+// Load reads a lockfile. A missing file is reported with an error wrapping
+// os.ErrNotExist.
+func Load(path string) (*Lockfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lockfile: %w", err)
+	}
+
+	lock := New()
+	if err := yaml.Unmarshal(data, lock); err != nil {
+		return nil, fmt.Errorf("failed to parse lockfile %s: %w", path, err)
+	}
+	if lock.Version > formatVersion {
+		return nil, fmt.Errorf("lockfile %s has format version %d; this devtool supports up to %d", path, lock.Version, formatVersion)
+	}
+	if lock.Tools == nil {
+		lock.Tools = make(map[string]Entry)
+	}
+
+	return lock, nil
+}
+
+// Save writes the lockfile atomically.
+func (l *Lockfile) Save(path string) error {
+	l.Version = formatVersion
+
+	var buf bytes.Buffer
+	buf.WriteString("# Generated by devtool install. Do not edit; use install --locked to reproduce.\n")
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(l); err != nil {
+		return fmt.Errorf("failed to marshal lockfile: %w", err)
+	}
+	data := buf.Bytes()
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write lockfile: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write lockfile: %w", err)
+	}
+
+	return nil
+}
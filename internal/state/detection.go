@@ -1,18 +1,21 @@
 package state
 
 import (
+	"context"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 
 	"github.com/lukeberry99/devtool/internal/config"
+	"github.com/lukeberry99/devtool/internal/executor"
 )
 
-type ToolDetector struct{}
+type ToolDetector struct {
+	executor executor.Executor
+}
 
-func NewToolDetector() *ToolDetector {
-	return &ToolDetector{}
+func NewToolDetector(exec executor.Executor) *ToolDetector {
+	return &ToolDetector{executor: exec}
 }
 
 func (d *ToolDetector) IsInstalled(toolName string, config config.ToolConfig) bool {
@@ -23,7 +26,7 @@ func (d *ToolDetector) IsInstalled(toolName string, config config.ToolConfig) bo
 		}
 		return d.IsApplicationInstalled(appName)
 	}
-	_, err := exec.LookPath(toolName)
+	_, err := d.executor.LookPath(toolName)
 	return err == nil
 }
 
@@ -33,24 +36,23 @@ func (d *ToolDetector) IsApplicationInstalled(appName string) bool {
 	return err == nil
 }
 
-func (d *ToolDetector) GetVersion(toolName string) (string, error) {
+func (d *ToolDetector) GetVersion(ctx context.Context, toolName string) (string, error) {
 	switch toolName {
 	case "go":
-		return d.getGoVersion()
+		return d.getGoVersion(ctx)
 	case "node":
-		return d.getNodeVersion()
+		return d.getNodeVersion(ctx)
 	case "nvim":
-		return d.getNeovimVersion()
+		return d.getNeovimVersion(ctx)
 	case "git":
-		return d.getGitVersion()
+		return d.getGitVersion(ctx)
 	default:
-		return d.getGenericVersion(toolName)
+		return d.getGenericVersion(ctx, toolName)
 	}
 }
 
-func (d *ToolDetector) getGoVersion() (string, error) {
-	cmd := exec.Command("go", "version")
-	output, err := cmd.Output()
+func (d *ToolDetector) getGoVersion(ctx context.Context) (string, error) {
+	output, err := d.output(ctx, "go", "version")
 	if err != nil {
 		return "", err
 	}
@@ -64,9 +66,8 @@ func (d *ToolDetector) getGoVersion() (string, error) {
 	return string(output), nil
 }
 
-func (d *ToolDetector) getNodeVersion() (string, error) {
-	cmd := exec.Command("node", "--version")
-	output, err := cmd.Output()
+func (d *ToolDetector) getNodeVersion(ctx context.Context) (string, error) {
+	output, err := d.output(ctx, "node", "--version")
 	if err != nil {
 		return "", err
 	}
@@ -74,9 +75,8 @@ func (d *ToolDetector) getNodeVersion() (string, error) {
 	return strings.TrimSpace(strings.TrimPrefix(string(output), "v")), nil
 }
 
-func (d *ToolDetector) getNeovimVersion() (string, error) {
-	cmd := exec.Command("nvim", "--version")
-	output, err := cmd.Output()
+func (d *ToolDetector) getNeovimVersion(ctx context.Context) (string, error) {
+	output, err := d.output(ctx, "nvim", "--version")
 	if err != nil {
 		return "", err
 	}
@@ -93,9 +93,8 @@ func (d *ToolDetector) getNeovimVersion() (string, error) {
 	return string(output), nil
 }
 
-func (d *ToolDetector) getGitVersion() (string, error) {
-	cmd := exec.Command("git", "--version")
-	output, err := cmd.Output()
+func (d *ToolDetector) getGitVersion(ctx context.Context) (string, error) {
+	output, err := d.output(ctx, "git", "--version")
 	if err != nil {
 		return "", err
 	}
@@ -109,13 +108,14 @@ func (d *ToolDetector) getGitVersion() (string, error) {
 	return string(output), nil
 }
 
-func (d *ToolDetector) getGenericVersion(toolName string) (string, error) {
+func (d *ToolDetector) getGenericVersion(ctx context.Context, toolName string) (string, error) {
 	// Try common version flags
 	versionFlags := []string{"--version", "-v", "version"}
 
 	for _, flag := range versionFlags {
-		cmd := exec.Command(toolName, flag)
-		output, err := cmd.Output()
+		// Unknown tools may do anything with these flags, so this is not a
+		// read-only query
+		output, err := executor.Output(ctx, d.executor, executor.New(toolName, flag))
 		if err == nil {
 			return strings.TrimSpace(string(output)), nil
 		}
@@ -123,3 +123,11 @@ func (d *ToolDetector) getGenericVersion(toolName string) (string, error) {
 
 	return "unknown", nil
 }
+
+// output runs a version query of a tool devtool knows and returns what it
+// printed.
+func (d *ToolDetector) output(ctx context.Context, name string, args ...string) ([]byte, error) {
+	cmd := executor.New(name, args...)
+	cmd.ReadOnly = true
+	return executor.Output(ctx, d.executor, cmd)
+}
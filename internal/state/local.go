@@ -1,16 +1,22 @@
 package state
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
+
+	"github.com/lukeberry99/devtool/internal/version"
 )
 
 type LocalStateManager struct {
 	statePath string
 	state     *LocalState
+	mu        sync.RWMutex
 }
 
 func NewLocalStateManager() (*LocalStateManager, error) {
@@ -65,11 +71,18 @@ func (m *LocalStateManager) load() error {
 		return err
 	}
 
+	if state.Tools == nil {
+		state.Tools = make(map[string]ToolStatus)
+	}
+
 	m.state = &state
 	return nil
 }
 
 func (m *LocalStateManager) Save() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	// Ensure directory exists
 	if err := os.MkdirAll(filepath.Dir(m.statePath), 0755); err != nil {
 		return fmt.Errorf("failed to create state directory: %w", err)
@@ -90,24 +103,146 @@ func (m *LocalStateManager) Save() error {
 }
 
 func (m *LocalStateManager) IsToolCurrent(name, expectedVersion string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
 	status, exists := m.state.Tools[name]
 	if !exists {
 		return false
 	}
 
-	return status.Installed && status.Version == expectedVersion
+	if !status.Installed {
+		return false
+	}
+	satisfied, _ := version.Satisfies(status.Version, expectedVersion)
+	return satisfied
 }
 
 func (m *LocalStateManager) UpdateToolStatus(name string, status ToolStatus) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	status.LastChecked = time.Now()
 	m.state.Tools[name] = status
 }
 
+func (m *LocalStateManager) RemoveToolStatus(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.state.Tools, name)
+}
+
 func (m *LocalStateManager) GetToolStatus(name string) (ToolStatus, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
 	status, exists := m.state.Tools[name]
 	return status, exists
 }
 
 func (m *LocalStateManager) GetAllTools() map[string]ToolStatus {
-	return m.state.Tools
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	tools := make(map[string]ToolStatus, len(m.state.Tools))
+	for name, status := range m.state.Tools {
+		tools[name] = status
+	}
+	return tools
+}
+
+// Fingerprint identifies what installs decide on in the recorded tools, so
+// callers can tell whether anything was installed, removed or updated since
+// they last looked. Timestamps and other bookkeeping are left out.
+func (m *LocalStateManager) Fingerprint() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	type decisive struct {
+		Installed, RolledBack                      bool
+		Version, Source, Commit, BuildHash, Prefix string
+		Tap, URL, Checksum                         string
+	}
+	tools := make(map[string]decisive, len(m.state.Tools))
+	for name, status := range m.state.Tools {
+		tools[name] = decisive{
+			Installed:  status.Installed,
+			RolledBack: status.RolledBack,
+			Version:    status.Version,
+			Source:     status.Source,
+			Commit:     status.Commit,
+			BuildHash:  status.BuildHash,
+			Prefix:     status.Prefix,
+			Tap:        status.Tap,
+			URL:        status.URL,
+			Checksum:   status.Checksum,
+		}
+	}
+
+	// Map keys are marshalled in sorted order, so equal states hash equally
+	data, _ := json.Marshal(tools)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// ActiveProfile returns the profile selected with SetActiveProfile.
+func (m *LocalStateManager) ActiveProfile() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.state.ActiveProfile
+}
+
+func (m *LocalStateManager) SetActiveProfile(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.state.ActiveProfile = name
+	m.state.ProfileSelected = true
+}
+
+// SelectedProfile returns the profile chosen with SetActiveProfile, or "" when
+// none was.
+func (m *LocalStateManager) SelectedProfile() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if !m.state.ProfileSelected {
+		return ""
+	}
+	return m.state.ActiveProfile
+}
+
+// Taps returns the Homebrew taps recorded with AddTap.
+func (m *LocalStateManager) Taps() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return append([]string{}, m.state.Taps...)
+}
+
+// AddTap records a Homebrew tap devtool added.
+func (m *LocalStateManager) AddTap(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, tap := range m.state.Taps {
+		if tap == name {
+			return
+		}
+	}
+	m.state.Taps = append(m.state.Taps, name)
+}
+
+func (m *LocalStateManager) RemoveTap(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, tap := range m.state.Taps {
+		if tap == name {
+			m.state.Taps = append(m.state.Taps[:i], m.state.Taps[i+1:]...)
+			return
+		}
+	}
 }
@@ -13,8 +13,14 @@ type LocalState struct {
 	Arch          string                `json:"arch"`
 	Tools         map[string]ToolStatus `json:"installed_tools"`
 	ActiveProfile string                `json:"active_profile"`
-	Preferences   MachinePreferences    `json:"preferences"`
-	LastBackup    time.Time             `json:"last_backup"`
+	// ProfileSelected is set once a profile was chosen with profile use;
+	// until then commands apply to every tool
+	ProfileSelected bool `json:"profile_selected,omitempty"`
+	// Taps lists the Homebrew taps devtool added, which are the only ones
+	// it will untap
+	Taps        []string           `json:"taps,omitempty"`
+	Preferences MachinePreferences `json:"preferences"`
+	LastBackup  time.Time          `json:"last_backup"`
 }
 
 // Enhanced ToolStatus to match rewrite.md structure
@@ -23,9 +29,37 @@ type ToolStatus struct {
 	Version       string    `json:"version"`
 	InstallDate   time.Time `json:"installed_at"`
 	LastChecked   time.Time `json:"last_checked"`
-	Source        string    `json:"source"` // "homebrew", "built_from_source", "manual"
+	Source        string    `json:"source"` // "homebrew", "system", "built_from_source", "script", "download", "release", "go", "cargo", "pipx", "npm", "manual"
 	BinaryPath    string    `json:"binary_path"`
 	ConfigCurrent bool      `json:"config_current"`
+	// Files lists paths devtool created for the tool, when it knows them
+	Files []string `json:"files,omitempty"`
+	// Commit and BuildHash identify the last successful source build
+	Commit    string `json:"commit,omitempty"`
+	BuildHash string `json:"build_hash,omitempty"`
+	// Prefix is the active install prefix of a source build; Prefixes keeps
+	// the retained builds, oldest first, for rollback
+	Prefix   string          `json:"prefix,omitempty"`
+	Prefixes []InstallPrefix `json:"prefixes,omitempty"`
+	// RolledBack pins a source build rolled back with devtool rollback;
+	// install leaves it alone until an upgrade or --rebuild
+	RolledBack bool `json:"rolled_back,omitempty"`
+	// Tap is the Homebrew tap a formula or cask was installed from, and Cask
+	// whether it was installed as a cask
+	Tap  string `json:"tap,omitempty"`
+	Cask bool   `json:"cask,omitempty"`
+	// URL and Checksum identify the artifact of downloads and releases
+	URL      string `json:"url,omitempty"`
+	Checksum string `json:"checksum,omitempty"`
+}
+
+// InstallPrefix is one retained source build.
+type InstallPrefix struct {
+	Path        string    `json:"path"`
+	Version     string    `json:"version"`
+	Commit      string    `json:"commit"`
+	BuildHash   string    `json:"build_hash"`
+	InstalledAt time.Time `json:"installed_at"`
 }
 
 // Machine preferences as outlined in rewrite.md
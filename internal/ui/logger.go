@@ -1,54 +1,85 @@
 package ui
 
 import (
+	"bytes"
 	"fmt"
+	"io"
+	"os"
+	"sync"
 
 	"github.com/fatih/color"
 )
 
 type Logger struct {
 	verbose bool
+	prefix  string
+	mu      *sync.Mutex
 }
 
 func NewLogger(verbose bool) *Logger {
 	return &Logger{
 		verbose: verbose,
+		mu:      &sync.Mutex{},
 	}
 }
 
+// WithPrefix returns a logger that tags every line with prefix. Loggers derived
+// from the same parent share a lock so concurrent output never interleaves
+// mid-line.
+func (l *Logger) WithPrefix(prefix string) *Logger {
+	return &Logger{
+		verbose: l.verbose,
+		prefix:  prefix,
+		mu:      l.mu,
+	}
+}
+
+func (l *Logger) println(w io.Writer, line string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.prefix != "" {
+		fmt.Fprintf(w, "%s %s\n", color.New(color.Faint).Sprintf("[%s]", l.prefix), line)
+		return
+	}
+	fmt.Fprintln(w, line)
+}
+
 func (l *Logger) Info(msg string) {
-	fmt.Printf("ℹ️  %s\n", msg)
+	l.println(color.Output, fmt.Sprintf("ℹ️  %s", msg))
 }
 
 func (l *Logger) Error(msg string) {
-	color.Red("❌ %s", msg)
+	l.println(color.Output, color.New(color.FgRed).Sprintf("❌ %s", msg))
 }
 
 func (l *Logger) Debug(msg string) {
 	if l.verbose {
-		color.Cyan("🔍 %s", msg)
+		l.println(color.Output, color.New(color.FgCyan).Sprintf("🔍 %s", msg))
 	}
 }
 
 func (l *Logger) Warn(msg string) {
-	color.Yellow("⚠️  %s", msg)
+	l.println(color.Output, color.New(color.FgYellow).Sprintf("⚠️  %s", msg))
 }
 
 func (l *Logger) Success(msg string) {
-	color.Green("✅ %s", msg)
+	l.println(color.Output, color.New(color.FgGreen).Sprintf("✅ %s", msg))
 }
 
 func (l *Logger) Progress(msg string) {
-	color.Blue("🔄 %s", msg)
+	l.println(color.Output, color.New(color.FgBlue).Sprintf("🔄 %s", msg))
 }
 
 func (l *Logger) Section(msg string) {
-	fmt.Println()
-	color.Cyan("🔧 %s", msg)
+	if l.prefix == "" {
+		l.println(color.Output, "")
+	}
+	l.println(color.Output, color.New(color.FgCyan).Sprintf("🔧 %s", msg))
 }
 
 func (l *Logger) Step(msg string) {
-	fmt.Printf("   • %s\n", msg)
+	l.println(color.Output, fmt.Sprintf("   • %s", msg))
 }
 
 func (l *Logger) Infof(format string, args ...interface{}) {
@@ -68,3 +99,57 @@ func (l *Logger) Debugf(format string, args ...interface{}) {
 func (l *Logger) Warnf(format string, args ...interface{}) {
 	l.Warn(fmt.Sprintf(format, args...))
 }
+
+// Writer returns a destination for subprocess stdout. Without a prefix this is
+// os.Stdout; with one, output is buffered per line and tagged like log lines.
+// Close flushes any trailing partial line.
+func (l *Logger) Writer() io.WriteCloser {
+	return l.writerFor(os.Stdout)
+}
+
+// ErrorWriter is the stderr counterpart of Writer.
+func (l *Logger) ErrorWriter() io.WriteCloser {
+	return l.writerFor(os.Stderr)
+}
+
+func (l *Logger) writerFor(w io.Writer) io.WriteCloser {
+	if l.prefix == "" {
+		return nopCloser{w}
+	}
+	return &lineWriter{logger: l, out: w}
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+type lineWriter struct {
+	logger *Logger
+	out    io.Writer
+	buf    bytes.Buffer
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.buf.Write(p)
+	for {
+		line, err := w.buf.ReadString('\n')
+		if err != nil {
+			// Keep the incomplete line for the next write
+			w.buf.Reset()
+			w.buf.WriteString(line)
+			break
+		}
+		w.logger.println(w.out, line[:len(line)-1])
+	}
+	return len(p), nil
+}
+
+func (w *lineWriter) Close() error {
+	if w.buf.Len() > 0 {
+		w.logger.println(w.out, w.buf.String())
+		w.buf.Reset()
+	}
+	return nil
+}
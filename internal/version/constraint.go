@@ -0,0 +1,224 @@
+package version
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Constraint is a set of alternatives separated by "||". Each alternative is
+// a list of comparators that must all hold.
+//
+// Supported comparators are =, !=, >, >=, <, <=, ~ (patch updates, or minor
+// updates when only a major version is given) and ^ (updates that keep the
+// left-most non-zero component). A bare version is exact when fully
+// specified and a range when partial: "1.22" and "1.22.x" mean ~1.22, and
+// every operator treats a partial version as that whole range, so <=1.22
+// includes 1.22.9 and !=1.22 excludes it.
+type Constraint struct {
+	raw          string
+	alternatives [][]Comparator
+}
+
+// Comparator is a single comparison, such as >=1.2.0-0, in the expanded form
+// of a constraint: only =, !=, >, >=, < and <= remain.
+type Comparator struct {
+	Op      string
+	Version Version
+}
+
+// ParseConstraint parses a constraint expression such as ">=0.10, <0.12",
+// "~1.22" or "^2 || ^3".
+func ParseConstraint(s string) (*Constraint, error) {
+	c := &Constraint{raw: s}
+
+	for _, alternative := range strings.Split(s, "||") {
+		terms := splitTerms(alternative)
+		if len(terms) == 0 {
+			return nil, fmt.Errorf("invalid version constraint %q: empty alternative", s)
+		}
+
+		// An alternative without comparators ("*") matches anything. A term
+		// with alternatives of its own (!=1.22) splits the alternative.
+		expanded := [][]Comparator{{}}
+		for _, term := range terms {
+			choices, err := parseTerm(term)
+			if err != nil {
+				return nil, fmt.Errorf("invalid version constraint %q: %w", s, err)
+			}
+			var next [][]Comparator
+			for _, comparators := range expanded {
+				for _, choice := range choices {
+					next = append(next, append(append([]Comparator{}, comparators...), choice...))
+				}
+			}
+			expanded = next
+		}
+		c.alternatives = append(c.alternatives, expanded...)
+	}
+
+	return c, nil
+}
+
+// splitTerms separates comparators on commas and whitespace, keeping an
+// operator attached to its version (">= 1.2" is one term).
+func splitTerms(s string) []string {
+	var terms []string
+	pending := ""
+	for _, field := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }) {
+		if strings.Trim(field, "=<>!~^") == "" {
+			pending += field
+			continue
+		}
+		terms = append(terms, pending+field)
+		pending = ""
+	}
+	if pending != "" {
+		terms = append(terms, pending)
+	}
+	return terms
+}
+
+// parseTerm expands a single term into alternatives of comparators that must
+// all hold.
+func parseTerm(term string) ([][]Comparator, error) {
+	op := term[:len(term)-len(strings.TrimLeft(term, "=<>!~^"))]
+	raw := strings.TrimLeft(strings.TrimPrefix(term, op), "vV")
+
+	// Wildcards make a version partial: 1.x and 1.* mean 1
+	parts := strings.Split(raw, ".")
+	for i, part := range parts {
+		if part == "x" || part == "X" || part == "*" {
+			parts = parts[:i]
+			break
+		}
+	}
+	if len(parts) == 0 {
+		if op == "" || op == "=" {
+			return [][]Comparator{{}}, nil // "*" matches anything
+		}
+		return nil, fmt.Errorf("wildcard cannot follow %s", op)
+	}
+
+	v, err := Parse(strings.Join(parts, "."))
+	if err != nil {
+		return nil, err
+	}
+
+	// A partial version stands for the range ~v
+	partial := v.Parts < 3
+	switch op {
+	case "", "=", "==":
+		if partial {
+			return [][]Comparator{tilde(v)}, nil
+		}
+		return [][]Comparator{{{"=", v}}}, nil
+	case ">=", "<":
+		return [][]Comparator{{{op, lowest(v)}}}, nil
+	case "<=":
+		if partial {
+			return [][]Comparator{{{"<", lowest(tildeUpper(v))}}}, nil
+		}
+		return [][]Comparator{{{op, v}}}, nil
+	case ">":
+		if partial {
+			return [][]Comparator{{{">=", lowest(tildeUpper(v))}}}, nil
+		}
+		return [][]Comparator{{{op, v}}}, nil
+	case "!=":
+		if partial {
+			return [][]Comparator{{{"<", lowest(v)}}, {{">=", lowest(tildeUpper(v))}}}, nil
+		}
+		return [][]Comparator{{{op, v}}}, nil
+	case "~", "~>":
+		return [][]Comparator{tilde(v)}, nil
+	case "^":
+		return [][]Comparator{caret(v)}, nil
+	default:
+		return nil, fmt.Errorf("unknown operator %q", op)
+	}
+}
+
+// tilde allows patch updates, or minor updates when only a major version is
+// given: ~1.22 is >=1.22.0 <1.23.0 and ~1 is >=1.0.0 <2.0.0.
+func tilde(v Version) []Comparator {
+	return []Comparator{{">=", lowest(v)}, {"<", lowest(tildeUpper(v))}}
+}
+
+// tildeUpper is the first version above the range ~v.
+func tildeUpper(v Version) Version {
+	if v.Parts >= 2 {
+		return Version{Major: v.Major, Minor: v.Minor + 1, Parts: 3}
+	}
+	return Version{Major: v.Major + 1, Parts: 3}
+}
+
+// caret allows updates that keep the left-most non-zero component:
+// ^2 is >=2.0.0 <3.0.0, ^0.10 is >=0.10.0 <0.11.0.
+func caret(v Version) []Comparator {
+	var upper Version
+	switch {
+	case v.Major > 0 || v.Parts == 1:
+		upper = Version{Major: v.Major + 1, Parts: 3}
+	case v.Minor > 0 || v.Parts == 2:
+		upper = Version{Minor: v.Minor + 1, Parts: 3}
+	default:
+		upper = Version{Patch: v.Patch + 1, Parts: 3}
+	}
+	return []Comparator{{">=", lowest(v)}, {"<", lowest(upper)}}
+}
+
+// lowest is the smallest version at or above v, including its prereleases:
+// ">=0.10" accepts "0.10.0-dev" and "<0.12" rejects "0.12.0-dev".
+func lowest(v Version) Version {
+	if v.Pre == "" {
+		v.Pre = "0"
+	}
+	return v
+}
+
+// Check reports whether v satisfies the constraint.
+func (c *Constraint) Check(v Version) bool {
+	for _, comparators := range c.alternatives {
+		ok := true
+		for _, cmp := range comparators {
+			if !cmp.matches(v) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Alternatives returns the constraint expanded into comparators, for
+// translating it into the syntax of another tool. An empty alternative
+// matches anything.
+func (c *Constraint) Alternatives() [][]Comparator {
+	return c.alternatives
+}
+
+func (c *Constraint) String() string {
+	return c.raw
+}
+
+func (cmp Comparator) matches(v Version) bool {
+	order := Compare(v, cmp.Version)
+	switch cmp.Op {
+	case "=":
+		return order == 0
+	case "!=":
+		return order != 0
+	case ">":
+		return order > 0
+	case ">=":
+		return order >= 0
+	case "<":
+		return order < 0
+	case "<=":
+		return order <= 0
+	}
+	return false
+}
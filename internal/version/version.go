@@ -0,0 +1,171 @@
+// Package version parses tool versions and checks them against the version
+// requirements in the configuration.
+package version
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Channels are version keywords that follow a release stream instead of
+// naming a version. Any installed version satisfies them; whether a newer one
+// exists is up to the installation source.
+var channels = map[string]bool{
+	"":        true,
+	"latest":  true,
+	"stable":  true,
+	"nightly": true,
+}
+
+// IsChannel reports whether s is a channel keyword rather than a version or
+// constraint.
+func IsChannel(s string) bool {
+	return channels[strings.ToLower(strings.TrimSpace(s))]
+}
+
+var commitPattern = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+
+// IsCommit reports whether s looks like a git commit hash rather than a
+// version.
+func IsCommit(s string) bool {
+	return commitPattern.MatchString(s) && strings.ContainsAny(s, "abcdef")
+}
+
+var plainPattern = regexp.MustCompile(`^[vV]?\d+(\.\d+){0,2}(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?$`)
+
+// IsConstraint reports whether s is a constraint such as ">=1.2" or "^2"
+// rather than a channel, a commit or a single, possibly partial, version.
+func IsConstraint(s string) bool {
+	s = strings.TrimSpace(s)
+	return !IsChannel(s) && !IsCommit(s) && !plainPattern.MatchString(s)
+}
+
+// Version is a parsed version. Parts records how many numeric components were
+// given, so "1.22" can be told apart from "1.22.0".
+type Version struct {
+	Major, Minor, Patch int
+	Parts               int
+	Pre                 string
+}
+
+var versionPattern = regexp.MustCompile(`(\d+)(?:\.(\d+))?(?:\.(\d+))?(?:\.\d+)*(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?`)
+
+// Parse extracts the first version from s. It accepts tags and command output
+// such as "v0.10.0-dev-1234+g1a2b3c", "go1.22.3" or "NVIM v0.9.1"; build
+// metadata is dropped.
+func Parse(s string) (Version, error) {
+	m := versionPattern.FindStringSubmatch(s)
+	if m == nil {
+		return Version{}, fmt.Errorf("no version found in %q", s)
+	}
+
+	var v Version
+	for i, field := range []*int{&v.Major, &v.Minor, &v.Patch} {
+		if m[i+1] == "" {
+			break
+		}
+		*field, _ = strconv.Atoi(m[i+1])
+		v.Parts++
+	}
+	v.Pre = m[4]
+	return v, nil
+}
+
+// Normalize returns the canonical form of a version string, e.g. "0.10.0-dev"
+// for "v0.10.0-dev+g1a2b3c". Strings without a version are returned trimmed.
+func Normalize(s string) string {
+	v, err := Parse(s)
+	if err != nil {
+		return strings.TrimSpace(s)
+	}
+	return v.String()
+}
+
+func (v Version) String() string {
+	parts := []string{strconv.Itoa(v.Major), strconv.Itoa(v.Minor), strconv.Itoa(v.Patch)}
+	s := strings.Join(parts[:max(v.Parts, 1)], ".")
+	if v.Pre != "" {
+		s += "-" + v.Pre
+	}
+	return s
+}
+
+// Compare returns -1, 0 or 1. Missing components count as zero and a
+// prerelease sorts before the release it precedes.
+func Compare(a, b Version) int {
+	for _, d := range []int{a.Major - b.Major, a.Minor - b.Minor, a.Patch - b.Patch} {
+		if d != 0 {
+			return sign(d)
+		}
+	}
+
+	switch {
+	case a.Pre == b.Pre:
+		return 0
+	case a.Pre == "":
+		return 1
+	case b.Pre == "":
+		return -1
+	}
+	return comparePre(a.Pre, b.Pre)
+}
+
+// comparePre orders prerelease strings by their dot or dash separated
+// identifiers, numerically where both are numbers.
+func comparePre(a, b string) int {
+	split := func(s string) []string {
+		return strings.FieldsFunc(s, func(r rune) bool { return r == '.' || r == '-' })
+	}
+	as, bs := split(a), split(b)
+
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, aErr := strconv.Atoi(as[i])
+		bn, bErr := strconv.Atoi(bs[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			if an != bn {
+				return sign(an - bn)
+			}
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(as[i], bs[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return sign(len(as) - len(bs))
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
+
+// Satisfies reports whether an installed version meets a requirement from
+// the configuration: a channel, an exact or partial version, or a constraint.
+func Satisfies(installed, requirement string) (bool, error) {
+	if IsChannel(requirement) {
+		return true, nil
+	}
+
+	constraint, err := ParseConstraint(requirement)
+	if err != nil {
+		return false, err
+	}
+
+	v, err := Parse(installed)
+	if err != nil {
+		return false, nil
+	}
+	return constraint.Check(v), nil
+}
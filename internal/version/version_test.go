@@ -0,0 +1,235 @@
+package version
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Version
+		wantErr bool
+	}{
+		{input: "1.22.3", want: Version{Major: 1, Minor: 22, Patch: 3, Parts: 3}},
+		{input: "v0.10.0-dev-1234+g1a2b3c", want: Version{Minor: 10, Parts: 3, Pre: "dev-1234"}},
+		{input: "go1.22.3", want: Version{Major: 1, Minor: 22, Patch: 3, Parts: 3}},
+		{input: "NVIM v0.9.1\nBuild type: Release", want: Version{Minor: 9, Patch: 1, Parts: 3}},
+		{input: "1.22", want: Version{Major: 1, Minor: 22, Parts: 2}},
+		{input: "2", want: Version{Major: 2, Parts: 1}},
+		{input: "1.2.3.4", want: Version{Major: 1, Minor: 2, Patch: 3, Parts: 3}},
+		{input: "latest", wantErr: true},
+		{input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Parse(%q) = %+v, want an error", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Errorf("Parse(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		input, want string
+	}{
+		{"v0.10.0-dev+g1a2b3c", "0.10.0-dev"},
+		{"go version go1.22.3 darwin/arm64", "1.22.3"},
+		{"v1.22", "1.22"},
+		{"  nightly  ", "nightly"},
+	}
+
+	for _, tt := range tests {
+		if got := Normalize(tt.input); got != tt.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.3", "1.2.3", 0},
+		{"1.2", "1.2.0", 0},
+		{"1.10.0", "1.9.0", 1},
+		{"1.2.3", "1.3.0", -1},
+		{"1.0.0-rc1", "1.0.0", -1},
+		{"1.0.0", "1.0.0-rc1", 1},
+		{"1.0.0-alpha.2", "1.0.0-alpha.10", -1},
+		{"1.0.0-1", "1.0.0-alpha", -1},
+		{"1.0.0-alpha", "1.0.0-alpha.1", -1},
+		{"1.0.0-beta", "1.0.0-alpha", 1},
+	}
+
+	for _, tt := range tests {
+		a, _ := Parse(tt.a)
+		b, _ := Parse(tt.b)
+		if got := Compare(a, b); got != tt.want {
+			t.Errorf("Compare(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSatisfies(t *testing.T) {
+	tests := []struct {
+		installed, requirement string
+		want                   bool
+	}{
+		// Channels accept anything
+		{"0.9.1", "", true},
+		{"0.9.1", "latest", true},
+		{"0.9.1", "Stable", true},
+
+		// Exact and partial versions
+		{"1.22.3", "1.22.3", true},
+		{"v1.22.3", "1.22.3", true},
+		{"1.22.4", "1.22.3", false},
+		{"1.22.7", "1.22", true},
+		{"1.23.0", "1.22", false},
+		{"1.22.7", "1.22.x", true},
+		{"1.9.0", "1", true},
+		{"2.0.0", "1.*", false},
+		{"5.0.0", "*", true},
+
+		// Comparators
+		{"0.10.0", ">=0.10, <0.12", true},
+		{"0.10.0-dev", ">=0.10", true},
+		{"0.12.0-dev", ">=0.10, <0.12", false},
+		{"0.11.9", ">= 0.10 < 0.12", true},
+		{"1.2.3", "!=1.2.3", false},
+		{"1.2.4", ">1.2.3", true},
+		{"1.2.3", "<=1.2.3", true},
+
+		// A partial version is the whole range on every operator
+		{"1.22.3", "<=1.22", true},
+		{"1.23.0-rc1", "<=1.22", false},
+		{"1.22.3", ">1.22", false},
+		{"1.23.0", ">1.22", true},
+		{"1.22.3", "!=1.22", false},
+		{"1.21.9", "!=1.22", true},
+		{"1.23.0", "!=1.22", true},
+		{"1.22.3", ">=1.22", true},
+		{"1.22.3", "<1.23", true},
+		{"1.22.3", "<1.22", false},
+		{"1.9.0", "<=1", true},
+		{"2.0.0", ">1", true},
+		{"1.5.0", "!=1.x", false},
+		{"1.22.3", ">=1.20, !=1.22", false},
+		{"1.21.0", ">=1.20, !=1.22", true},
+
+		// Tilde and caret
+		{"1.22.9", "~1.22", true},
+		{"1.23.0", "~1.22", false},
+		{"1.9.0", "~1", true},
+		{"1.4.2", "~>1.4.1", true},
+		{"2.9.0", "^2", true},
+		{"3.0.0", "^2", false},
+		{"0.10.5", "^0.10", true},
+		{"0.11.0", "^0.10", false},
+		{"0.0.3", "^0.0.3", true},
+		{"0.0.4", "^0.0.3", false},
+
+		// Alternatives
+		{"3.1.0", "^2 || ^3", true},
+		{"4.0.0", "^2 || ^3", false},
+
+		// An installed string without a version never satisfies a version
+		{"unknown", "1.2.3", false},
+	}
+
+	for _, tt := range tests {
+		got, err := Satisfies(tt.installed, tt.requirement)
+		if err != nil {
+			t.Errorf("Satisfies(%q, %q): %v", tt.installed, tt.requirement, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Satisfies(%q, %q) = %v, want %v", tt.installed, tt.requirement, got, tt.want)
+		}
+	}
+}
+
+func TestParseConstraintErrors(t *testing.T) {
+	for _, constraint := range []string{">=1 ||", ">=*", "=>1.2", "not a version"} {
+		if _, err := ParseConstraint(constraint); err == nil {
+			t.Errorf("ParseConstraint(%q) succeeded, want an error", constraint)
+		}
+	}
+}
+
+func TestAlternatives(t *testing.T) {
+	tests := []struct {
+		constraint string
+		want       []string
+	}{
+		{"~1.22 || =2.0.0", []string{">=1.22-0 <1.23.0-0", "=2.0.0"}},
+		{"*", []string{""}},
+		{">=1, !=1.2", []string{">=1-0 <1.2-0", ">=1-0 >=1.3.0-0"}},
+	}
+
+	for _, tt := range tests {
+		c, err := ParseConstraint(tt.constraint)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got []string
+		for _, comparators := range c.Alternatives() {
+			var terms []string
+			for _, cmp := range comparators {
+				terms = append(terms, cmp.Op+cmp.Version.String())
+			}
+			got = append(got, strings.Join(terms, " "))
+		}
+		if strings.Join(got, " || ") != strings.Join(tt.want, " || ") || len(got) != len(tt.want) {
+			t.Errorf("ParseConstraint(%q).Alternatives() = %q, want %q", tt.constraint, got, tt.want)
+		}
+	}
+}
+
+func TestClassification(t *testing.T) {
+	tests := []struct {
+		input                         string
+		channel, commit, isConstraint bool
+	}{
+		{input: "", channel: true},
+		{input: "latest", channel: true},
+		{input: "NIGHTLY", channel: true},
+		{input: "1.22.3"},
+		{input: "v1.22"},
+		{input: "1.0.0-rc.1+build.5"},
+		{input: "1a2b3c4", commit: true},
+		{input: "0123456789abcdef0123456789abcdef01234567", commit: true},
+		{input: "1234567"},
+		{input: ">=1.2", isConstraint: true},
+		{input: "^2", isConstraint: true},
+		{input: "1.x", isConstraint: true},
+		{input: "1.2 || 2", isConstraint: true},
+	}
+
+	for _, tt := range tests {
+		if got := IsChannel(tt.input); got != tt.channel {
+			t.Errorf("IsChannel(%q) = %v, want %v", tt.input, got, tt.channel)
+		}
+		if got := IsCommit(tt.input); got != tt.commit {
+			t.Errorf("IsCommit(%q) = %v, want %v", tt.input, got, tt.commit)
+		}
+		if got := IsConstraint(tt.input); got != tt.isConstraint {
+			t.Errorf("IsConstraint(%q) = %v, want %v", tt.input, got, tt.isConstraint)
+		}
+	}
+}